@@ -0,0 +1,114 @@
+// Package bsdf holds the ways surfaces scatter light.
+package bsdf
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// BSDF is the interface for the ways surfaces scatter light. Directions are
+// unit vectors pointing away from the surface, and n is the unit normal of the
+// surface pointing out of its object.
+type BSDF interface {
+	// Eval returns the light scattered towards e for each unit of light
+	// arriving from l, leaving out any specular part.
+	Eval(l, e, n vector.Vector) colour.Colour
+	// Sample chooses a direction to gather light from for the eye direction e,
+	// using the random numbers u0, u1 and u2 in [0, 1).
+	Sample(e, n vector.Vector, u0, u1, u2 float64) Sample
+	// PDF returns the probability density by solid angle of Sample choosing l,
+	// leaving out any specular part.
+	PDF(l, e, n vector.Vector) float64
+}
+
+// Sample is a direction chosen by a BSDF.
+type Sample struct {
+	Direction vector.Vector
+	// Weight is the fraction of the light from Direction scattered to the eye,
+	// which is Eval times the cosine of Direction to the normal over PDF.
+	Weight colour.Colour
+	// PDF is zero when no direction could be chosen. For specular samples it is
+	// the probability of choosing the direction.
+	PDF float64
+	// Specular is true when Direction is one of a few which scatter light to
+	// the eye, such as a mirror reflection.
+	Specular bool
+}
+
+// SpecularBSDF is implemented by BSDFs with specular parts, which can list
+// every direction they scatter light from with its weight.
+type SpecularBSDF interface {
+	SpecularSamples(e, n vector.Vector) []Sample
+}
+
+// Anisotropic is implemented by BSDFs which may depend on the direction of the
+// surface's tangent.
+type Anisotropic interface {
+	// WithTangent returns the BSDF for a surface with the unit tangent t.
+	WithTangent(t vector.Vector) BSDF
+	// NeedsTangent returns true if the BSDF depends on the tangent.
+	NeedsTangent() bool
+}
+
+// Transmitter is implemented by BSDFs of transparent surfaces, which let the
+// light of shadow rays through.
+type Transmitter interface {
+	// Transmission returns the fraction of light passing straight through
+	// the surface, which is black when it is opaque.
+	Transmission() colour.Colour
+}
+
+// transmission returns the fraction of light passing straight through a
+// surface with BSDF b.
+func transmission(b BSDF) colour.Colour {
+	if t, ok := b.(Transmitter); ok {
+		return t.Transmission()
+	}
+	return colour.New(0, 0, 0)
+}
+
+// Orient returns b for a surface with the unit tangent t.
+func Orient(b BSDF, t vector.Vector) BSDF {
+	if a, ok := b.(Anisotropic); ok {
+		return a.WithTangent(t)
+	}
+	return b
+}
+
+// NeedsTangent returns true if b depends on the direction of the surface's
+// tangent, so it must be oriented with Orient.
+func NeedsTangent(b BSDF) bool {
+	a, ok := b.(Anisotropic)
+	return ok && a.NeedsTangent()
+}
+
+// sampleFrom returns a Sample of l for a BSDF.
+func sampleFrom(b BSDF, l, e, n vector.Vector) Sample {
+	pdf := b.PDF(l, e, n)
+	if pdf <= 0 {
+		return Sample{Direction: l, Weight: colour.New(0, 0, 0)}
+	}
+	weight := b.Eval(l, e, n).ScalarMult(math.Abs(vector.DotProduct(l, n)) / pdf)
+	return Sample{Direction: l, Weight: weight, PDF: pdf}
+}
+
+// faceForward returns n turned to the side of the surface facing e.
+func faceForward(n, e vector.Vector) vector.Vector {
+	if vector.DotProduct(n, e) < 0 {
+		return n.Negate()
+	}
+	return n
+}
+
+// sameSide returns true if l and e are on the same side of the surface with
+// normal n.
+func sameSide(l, e, n vector.Vector) bool {
+	return vector.DotProduct(l, n)*vector.DotProduct(e, n) > 0
+}
+
+// reflect returns the mirror reflection of the direction e about n.
+func reflect(e, n vector.Vector) vector.Vector {
+	return vector.Subtract(n.ScalarMultiply(2*vector.DotProduct(e, n)), e)
+}
@@ -0,0 +1,58 @@
+package bsdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+var (
+	up      = vector.NewVector(0, 1, 0)
+	oblique = vector.NewVector(0, math.Sqrt2/2, -math.Sqrt2/2)
+)
+
+// albedos returns the red albedo of b for the eye direction e about n,
+// estimated with its own samples and with uniform samples over the sphere.
+// They agree if its Sample, Eval and PDF agree.
+func albedos(b BSDF, e, n vector.Vector) (float64, float64) {
+	const samples = 40000
+	sampled, uniform := 0.0, 0.0
+	for i := 0; i < samples; i++ {
+		s := b.Sample(e, n, sampling.Float64(), sampling.Float64(), sampling.Float64())
+		if s.PDF > 0 && !s.Specular {
+			sampled += s.Weight.Red
+		}
+		u := sampling.UniformSphere(sampling.Float64(), sampling.Float64())
+		uniform += b.Eval(u, e, n).Red * math.Abs(vector.DotProduct(u, n)) * 4 * math.Pi
+	}
+	return sampled / samples, uniform / samples
+}
+
+func checkAlbedo(t *testing.T, b BSDF, e, n vector.Vector, expected float64) {
+	sampled, uniform := albedos(b, e, n)
+	if math.Abs(sampled-uniform) > 0.03 {
+		t.Errorf("Albedo of %+v was %v sampled and %v uniformly, expected them to agree.", b, sampled, uniform)
+	}
+	if expected >= 0 && math.Abs(sampled-expected) > 0.03 {
+		t.Errorf("Albedo of %+v was %v, expected %v.", b, sampled, expected)
+	}
+}
+
+func TestReflect(t *testing.T) {
+	result := reflect(oblique, up)
+	expected := vector.NewVector(0, math.Sqrt2/2, math.Sqrt2/2)
+	if !vector.Equal(result, expected) {
+		t.Errorf("Reflection of %v was %v, expected %v.", oblique, result, expected)
+	}
+}
+
+func TestFaceForward(t *testing.T) {
+	if result := faceForward(up, oblique.Negate()); !vector.Equal(result, up.Negate()) {
+		t.Errorf("Normal facing away from the eye was turned to %v.", result)
+	}
+	if result := faceForward(up, oblique); !vector.Equal(result, up) {
+		t.Errorf("Normal facing the eye was turned to %v.", result)
+	}
+}
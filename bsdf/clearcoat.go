@@ -0,0 +1,168 @@
+package bsdf
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// ClearCoat is a layer of dielectric, such as lacquer, over a Base BSDF. Light
+// reaching the base is reduced by the Fresnel reflectance of the coat on the
+// way in and out, and tinted by Tint, the colour of light passing down through
+// the coat and back up at normal incidence, which is clear when it is zero. A
+// coat with a Roughness of zero is a specular mirror. An IOR below one, which
+// no coat has, is taken as one.
+type ClearCoat struct {
+	Base      BSDF
+	IOR       float64
+	Roughness float64
+	Tint      colour.Colour
+}
+
+// ior returns the IOR of the coat, at least one.
+func (b ClearCoat) ior() float64 {
+	return math.Max(1, b.IOR)
+}
+
+// tint returns the Tint of the coat, or white for an unset Tint.
+func (b ClearCoat) tint() colour.Colour {
+	if b.Tint == colour.New(0, 0, 0) {
+		return colour.New(1, 1, 1)
+	}
+	return b.Tint
+}
+
+// fresnel returns the reflectance of the coat for light at cosI to its normal.
+func (b ClearCoat) fresnel(cosI float64) float64 {
+	eta := 1 / b.ior()
+	sin2T := eta * eta * (1 - cosI*cosI)
+	return FresnelDielectric(cosI, math.Sqrt(math.Max(0, 1-sin2T)), eta)
+}
+
+// transmission returns the fraction of light which passes through the coat
+// from the direction at cosL to the normal, and back out to the direction at
+// cosE.
+func (b ClearCoat) transmission(cosL, cosE float64) colour.Colour {
+	// Path lengths through the coat grow as the cosines of the refracted
+	// directions fall.
+	eta2 := 1 / (b.ior() * b.ior())
+	cosTL := math.Sqrt(1 - eta2*(1-cosL*cosL))
+	cosTE := math.Sqrt(1 - eta2*(1-cosE*cosE))
+	exponent := (1/cosTL + 1/cosTE) / 2
+	tint := b.tint()
+	absorbed := colour.New(
+		math.Pow(tint.Red, exponent), math.Pow(tint.Green, exponent), math.Pow(tint.Blue, exponent),
+	)
+	return absorbed.ScalarMult((1 - b.fresnel(cosL)) * (1 - b.fresnel(cosE)))
+}
+
+// coatChance returns the probability of sampling the coat for the eye direction
+// at cosE to the normal.
+func (b ClearCoat) coatChance(cosE float64) float64 {
+	return math.Min(0.9, math.Max(0.1, b.fresnel(cosE)))
+}
+
+// Eval returns the light scattered towards e from l by the coat and the base.
+func (b ClearCoat) Eval(l, e, n vector.Vector) colour.Colour {
+	cosL, cosE := math.Abs(vector.DotProduct(l, n)), math.Abs(vector.DotProduct(e, n))
+	if cosL == 0 || cosE == 0 {
+		return colour.New(0, 0, 0)
+	}
+	c := b.Base.Eval(l, e, n).Mult(b.transmission(cosL, cosE))
+	if b.Roughness > 0 && sameSide(l, e, n) {
+		facing := faceForward(n, e)
+		h := vector.Add(l, e)
+		h = h.Normalize()
+		g := isotropic(b.Roughness)
+		d := g.d(h, facing) * g.g1(l, facing) * g.g1(e, facing)
+		coat := b.fresnel(vector.DotProduct(e, h)) * d / (4 * cosL * cosE)
+		c = c.Add(colour.New(coat, coat, coat))
+	}
+	return c
+}
+
+// Sample chooses a direction from the coat or the base by u0.
+func (b ClearCoat) Sample(e, n vector.Vector, u0, u1, u2 float64) Sample {
+	facing := faceForward(n, e)
+	cosE := vector.DotProduct(e, facing)
+	chance := b.coatChance(cosE)
+	if u0 < chance {
+		if b.Roughness == 0 {
+			f := b.fresnel(cosE) / chance
+			return Sample{
+				Direction: reflect(e, facing), Weight: colour.New(f, f, f), PDF: chance, Specular: true,
+			}
+		}
+		return sampleFrom(b, isotropic(b.Roughness).sample(e, facing, u1, u2), e, n)
+	}
+	s := b.Base.Sample(e, n, (u0-chance)/(1-chance), u1, u2)
+	if s.PDF <= 0 {
+		return s
+	}
+	if s.Specular {
+		cosL := math.Abs(vector.DotProduct(s.Direction, n))
+		s.Weight = s.Weight.Mult(b.transmission(cosL, cosE)).ScalarMult(1 / (1 - chance))
+		s.PDF *= 1 - chance
+		return s
+	}
+	return sampleFrom(b, s.Direction, e, n)
+}
+
+// PDF returns the probability density of Sample choosing l.
+func (b ClearCoat) PDF(l, e, n vector.Vector) float64 {
+	facing := faceForward(n, e)
+	chance := b.coatChance(vector.DotProduct(e, facing))
+	pdf := (1 - chance) * b.Base.PDF(l, e, n)
+	if b.Roughness > 0 {
+		pdf += chance * isotropic(b.Roughness).pdf(l, e, facing)
+	}
+	return pdf
+}
+
+// SpecularSamples returns the mirror reflection of a smooth coat and the
+// specular samples of the base, reduced by the coat.
+func (b ClearCoat) SpecularSamples(e, n vector.Vector) []Sample {
+	facing := faceForward(n, e)
+	cosE := vector.DotProduct(e, facing)
+	var samples []Sample
+	if b.Roughness == 0 {
+		f := b.fresnel(cosE)
+		samples = append(samples, Sample{
+			Direction: reflect(e, facing), Weight: colour.New(f, f, f), PDF: f, Specular: true,
+		})
+	}
+	if base, ok := b.Base.(SpecularBSDF); ok {
+		for _, s := range base.SpecularSamples(e, n) {
+			cosL := math.Abs(vector.DotProduct(s.Direction, n))
+			s.Weight = s.Weight.Mult(b.transmission(cosL, cosE))
+			samples = append(samples, s)
+		}
+	}
+	return samples
+}
+
+// Transmission returns the transmission of the base, reduced by the Fresnel
+// reflectance of the coat at normal incidence and tinted by one pass through
+// it.
+func (b ClearCoat) Transmission() colour.Colour {
+	tint := b.tint()
+	tint = colour.New(math.Sqrt(tint.Red), math.Sqrt(tint.Green), math.Sqrt(tint.Blue))
+	return transmission(b.Base).Mult(tint).ScalarMult(1 - b.fresnel(1))
+}
+
+// WithTangent returns the coat over its base oriented along t.
+func (b ClearCoat) WithTangent(t vector.Vector) BSDF {
+	b.Base = Orient(b.Base, t)
+	return b
+}
+
+// NeedsTangent returns true if the base needs the tangent.
+func (b ClearCoat) NeedsTangent() bool {
+	return NeedsTangent(b.Base)
+}
+
+// NewClearCoat returns a colourless coat over base.
+func NewClearCoat(base BSDF, ior, roughness float64) ClearCoat {
+	return ClearCoat{Base: base, IOR: ior, Roughness: roughness, Tint: colour.New(1, 1, 1)}
+}
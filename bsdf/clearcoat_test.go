@@ -0,0 +1,115 @@
+package bsdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestClearCoatEval(t *testing.T) {
+	var tests = []struct {
+		coat     ClearCoat
+		expected colour.Colour
+	}{
+		{
+			// Head on, the base loses 4% of light on the way in and out.
+			coat:     NewClearCoat(Lambert{Albedo: colour.New(1, 1, 1)}, 1.5, 0),
+			expected: colour.New(0.9216, 0.9216, 0.9216),
+		},
+		{
+			coat: ClearCoat{
+				Base: Lambert{Albedo: colour.New(1, 1, 1)}, IOR: 1.5, Tint: colour.New(0.25, 1, 0.5),
+			},
+			expected: colour.New(0.2304, 0.9216, 0.4608),
+		},
+		{
+			// A coat with no tint is clear.
+			coat:     ClearCoat{Base: Lambert{Albedo: colour.New(1, 1, 1)}, IOR: 1.5},
+			expected: colour.New(0.9216, 0.9216, 0.9216),
+		},
+		{
+			// A rough coat adds its own highlight.
+			coat:     NewClearCoat(Lambert{Albedo: colour.New(0, 0, 0)}, 1.5, 1),
+			expected: colour.New(0.01, 0.01, 0.01),
+		},
+	}
+	for _, test := range tests {
+		result := test.coat.Eval(up, up, up).ScalarMult(math.Pi)
+		if result.Equal(test.expected) != true {
+			t.Errorf("Clear coat %+v head on was %v, expected %v.", test.coat, result, test.expected)
+		}
+	}
+}
+
+func TestClearCoatLowIOR(t *testing.T) {
+	// A coat with an IOR below one is taken as one, which lets all light
+	// through.
+	for _, ior := range []float64{0, 0.5, 1} {
+		b := ClearCoat{Base: Lambert{Albedo: colour.New(1, 1, 1)}, IOR: ior}
+		result := b.Eval(oblique, oblique, up).ScalarMult(math.Pi)
+		if result.Equal(colour.New(1, 1, 1)) != true {
+			t.Errorf("Clear coat with IOR %v was %v, expected white.", ior, result)
+		}
+	}
+}
+
+func TestClearCoatSpecularSamples(t *testing.T) {
+	b := NewClearCoat(NewDielectric(1.5), 1.5, 0)
+	samples := b.SpecularSamples(up, up)
+	expected := []struct {
+		direction vector.Vector
+		weight    float64
+	}{
+		{direction: up, weight: 0.04},
+		{direction: up, weight: 0.04 * 0.9216},
+		{direction: up.Negate(), weight: 0.96 * 0.9216},
+	}
+	if len(samples) != len(expected) {
+		t.Fatalf("Coated glass had %d specular samples, expected %d.", len(samples), len(expected))
+	}
+	for i, s := range samples {
+		if !vector.Equal(s.Direction, expected[i].direction) ||
+			math.Abs(s.Weight.Red-expected[i].weight) > 1e-5 {
+			t.Errorf("Coated glass sample %d was %+v, expected %+v.", i, s, expected[i])
+		}
+	}
+}
+
+func TestClearCoatSample(t *testing.T) {
+	b := NewClearCoat(Lambert{Albedo: colour.New(1, 1, 1)}, 1.5, 0)
+	s := b.Sample(oblique, up, 0.01, 0.5, 0.5)
+	if !s.Specular || !vector.Equal(s.Direction, reflect(oblique, up)) ||
+		math.Abs(s.Weight.Red*s.PDF-b.fresnel(math.Sqrt2/2)) > 1e-5 {
+		t.Errorf("Clear coat mirror sample was %+v.", s)
+	}
+	// The base and a rough coat reflect no more light than falls on them.
+	checkAlbedo(t, b, oblique, up, -1)
+	rough := NewClearCoat(Lambert{Albedo: colour.New(1, 1, 1)}, 1.5, 0.7)
+	checkAlbedo(t, rough, oblique, up, -1)
+	if sampled, _ := albedos(rough, oblique, up); sampled > 1 {
+		t.Errorf("Rough clear coat over white reflected %v of its light.", sampled)
+	}
+}
+
+func TestClearCoatTransmission(t *testing.T) {
+	glass := Dielectric{IOR: 1.5, Tint: colour.New(1, 0.5, 0)}
+	var tests = []struct {
+		coat     ClearCoat
+		expected colour.Colour
+	}{
+		{coat: NewClearCoat(glass, 1.5, 0), expected: colour.New(0.96, 0.48, 0)},
+		{
+			coat:     ClearCoat{Base: glass, IOR: 1.5, Tint: colour.New(1, 0.25, 1)},
+			expected: colour.New(0.96, 0.24, 0),
+		},
+		// Coats over opaque surfaces let no light through.
+		{coat: NewClearCoat(Lambert{Albedo: colour.New(1, 1, 1)}, 1.5, 0), expected: colour.New(0, 0, 0)},
+	}
+	for _, test := range tests {
+		if result := test.coat.Transmission(); result.Equal(test.expected) != true {
+			t.Errorf("Transmission of %+v was %v, expected %v.", test.coat, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package bsdf
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Dielectric is a smooth transparent surface, such as glass or water, with an
+// index of refraction IOR. Transmitted light is multiplied by Tint.
+type Dielectric struct {
+	IOR  float64
+	Tint colour.Colour
+}
+
+// Eval returns black, as a smooth dielectric only scatters light specularly.
+func (b Dielectric) Eval(l, e, n vector.Vector) colour.Colour {
+	return colour.New(0, 0, 0)
+}
+
+// Sample chooses the reflection or refraction of e, in proportion to the
+// Fresnel reflectance.
+func (b Dielectric) Sample(e, n vector.Vector, u0, u1, u2 float64) Sample {
+	samples := b.SpecularSamples(e, n)
+	reflectance := samples[0].PDF
+	if u0 < reflectance || len(samples) == 1 {
+		return Sample{
+			Direction: samples[0].Direction, Weight: colour.New(1, 1, 1), PDF: reflectance,
+			Specular: true,
+		}
+	}
+	return Sample{
+		Direction: samples[1].Direction, Weight: b.Tint, PDF: 1 - reflectance, Specular: true,
+	}
+}
+
+// PDF returns zero, as a smooth dielectric only scatters light specularly.
+func (b Dielectric) PDF(l, e, n vector.Vector) float64 {
+	return 0
+}
+
+// SpecularSamples returns the reflection of e and, unless it is totally
+// internally reflected, its refraction. Their weights are the fractions of
+// light reflected and transmitted, which are also their PDFs.
+func (b Dielectric) SpecularSamples(e, n vector.Vector) []Sample {
+	eta := 1 / b.IOR
+	if vector.DotProduct(e, n) < 0 {
+		// Leaving the object.
+		eta = b.IOR
+		n = n.Negate()
+	}
+	cosI := vector.DotProduct(e, n)
+	sin2T := eta * eta * (1 - cosI*cosI)
+	reflection := reflect(e, n)
+	if sin2T >= 1 {
+		return []Sample{{Direction: reflection, Weight: colour.New(1, 1, 1), PDF: 1, Specular: true}}
+	}
+	cosT := math.Sqrt(1 - sin2T)
+	f := FresnelDielectric(cosI, cosT, eta)
+	refraction := vector.Subtract(n.ScalarMultiply(eta*cosI-cosT), e.ScalarMultiply(eta))
+	return []Sample{
+		{Direction: reflection, Weight: colour.New(f, f, f), PDF: f, Specular: true},
+		{Direction: refraction, Weight: b.Tint.ScalarMult(1 - f), PDF: 1 - f, Specular: true},
+	}
+}
+
+// Transmission returns the tint, so shadows of clear dielectrics take the colour
+// of the light refracted through them.
+func (b Dielectric) Transmission() colour.Colour {
+	return b.Tint
+}
+
+// FresnelDielectric returns the fraction of unpolarised light reflected at a
+// smooth boundary, for light at cosI to the normal refracted to cosT, where eta
+// is the ratio of the indices of refraction of the first and second sides.
+func FresnelDielectric(cosI, cosT, eta float64) float64 {
+	parallel := (cosI - eta*cosT) / (cosI + eta*cosT)
+	perpendicular := (eta*cosI - cosT) / (eta*cosI + cosT)
+	return (parallel*parallel + perpendicular*perpendicular) / 2
+}
+
+// NewDielectric returns a clear dielectric with an index of refraction.
+func NewDielectric(ior float64) Dielectric {
+	return Dielectric{IOR: ior, Tint: colour.New(1, 1, 1)}
+}
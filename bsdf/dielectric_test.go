@@ -0,0 +1,109 @@
+package bsdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestFresnelDielectric(t *testing.T) {
+	var tests = []struct {
+		cosI, cosT, eta, expected float64
+	}{
+		// Head on from air into glass.
+		{cosI: 1, cosT: 1, eta: 1 / 1.5, expected: 0.04},
+		// Matched indices reflect nothing.
+		{cosI: 0.5, cosT: 0.5, eta: 1, expected: 0},
+		// Grazing light is all reflected.
+		{cosI: 0, cosT: 0.5, eta: 1 / 1.5, expected: 1},
+	}
+	for _, test := range tests {
+		result := FresnelDielectric(test.cosI, test.cosT, test.eta)
+		if comparison.EpsilonEqual(result, test.expected) != true {
+			t.Errorf("Fresnel reflectance for %+v was %v, expected %v.", test, result, test.expected)
+		}
+	}
+}
+
+func TestDielectricSpecularSamples(t *testing.T) {
+	b := Dielectric{IOR: 1.5, Tint: colour.New(1, 0.5, 1)}
+	var tests = []struct {
+		name       string
+		e          vector.Vector
+		directions []vector.Vector
+		weights    []colour.Colour
+	}{
+		{
+			name:       "entering head on",
+			e:          up,
+			directions: []vector.Vector{up, up.Negate()},
+			weights:    []colour.Colour{colour.New(0.04, 0.04, 0.04), colour.New(0.96, 0.48, 0.96)},
+		},
+		{
+			name:       "leaving head on",
+			e:          up.Negate(),
+			directions: []vector.Vector{up.Negate(), up},
+			weights:    []colour.Colour{colour.New(0.04, 0.04, 0.04), colour.New(0.96, 0.48, 0.96)},
+		},
+		{
+			// Past the critical angle of about 42 degrees.
+			name:       "leaving at 45 degrees",
+			e:          oblique.Negate(),
+			directions: []vector.Vector{vector.NewVector(0, -math.Sqrt2/2, -math.Sqrt2/2)},
+			weights:    []colour.Colour{colour.New(1, 1, 1)},
+		},
+	}
+	for _, test := range tests {
+		samples := b.SpecularSamples(test.e, up)
+		if len(samples) != len(test.directions) {
+			t.Errorf("Dielectric %s had %d samples, expected %d.", test.name, len(samples), len(test.directions))
+			continue
+		}
+		for i, s := range samples {
+			if !vector.Equal(s.Direction, test.directions[i]) || s.Weight.Equal(test.weights[i]) != true {
+				t.Errorf(
+					"Dielectric %s sample %d was %+v, expected direction %v and weight %v.",
+					test.name, i, s, test.directions[i], test.weights[i],
+				)
+			}
+		}
+	}
+}
+
+func TestDielectricRefraction(t *testing.T) {
+	// Snell's law at 45 degrees into glass.
+	b := NewDielectric(1.5)
+	samples := b.SpecularSamples(oblique, up)
+	sinT := math.Sqrt2 / 2 / 1.5
+	expected := vector.NewVector(0, -math.Sqrt(1-sinT*sinT), sinT)
+	if len(samples) != 2 || !vector.Equal(samples[1].Direction, expected) {
+		t.Errorf("Refraction at 45 degrees was %+v, expected direction %v.", samples, expected)
+	}
+}
+
+func TestDielectricSample(t *testing.T) {
+	b := NewDielectric(1.5)
+	reflection := b.Sample(up, up, 0.01, 0.5, 0.5)
+	refraction := b.Sample(up, up, 0.5, 0.5, 0.5)
+	if !reflection.Specular || !vector.Equal(reflection.Direction, up) ||
+		comparison.EpsilonEqual(reflection.PDF, 0.04) != true {
+		t.Errorf("Dielectric reflection sample was %+v.", reflection)
+	}
+	if !refraction.Specular || !vector.Equal(refraction.Direction, up.Negate()) ||
+		refraction.Weight.Equal(colour.New(1, 1, 1)) != true {
+		t.Errorf("Dielectric refraction sample was %+v.", refraction)
+	}
+	if b.PDF(up, up, up) != 0 || b.Eval(up, up, up).Equal(colour.New(0, 0, 0)) != true {
+		t.Error("Dielectric eval and pdf were not zero.")
+	}
+}
+
+func TestDielectricTransmission(t *testing.T) {
+	var b Transmitter = Dielectric{IOR: 1.5, Tint: colour.New(1, 0.5, 0)}
+	if result := b.Transmission(); result != colour.New(1, 0.5, 0) {
+		t.Errorf("Dielectric transmission was %v, expected its tint.", result)
+	}
+}
@@ -0,0 +1,94 @@
+package bsdf
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Lambert is a perfectly diffuse surface.
+type Lambert struct {
+	Albedo colour.Colour
+}
+
+// Eval returns the light scattered towards e from l.
+func (b Lambert) Eval(l, e, n vector.Vector) colour.Colour {
+	if !sameSide(l, e, n) {
+		return colour.New(0, 0, 0)
+	}
+	return b.Albedo.ScalarMult(1 / math.Pi)
+}
+
+// Sample chooses a direction with a cosine weighted distribution.
+func (b Lambert) Sample(e, n vector.Vector, u0, u1, u2 float64) Sample {
+	return sampleFrom(b, sampling.CosineHemisphere(faceForward(n, e), u1, u2), e, n)
+}
+
+// PDF returns the probability density of Sample choosing l.
+func (b Lambert) PDF(l, e, n vector.Vector) float64 {
+	return cosinePDF(l, e, n)
+}
+
+// OrenNayar is a rough diffuse surface, with Sigma the standard deviation in
+// radians of the angles of its facets.
+type OrenNayar struct {
+	Albedo colour.Colour
+	Sigma  float64
+}
+
+// Eval returns the light scattered towards e from l.
+func (b OrenNayar) Eval(l, e, n vector.Vector) colour.Colour {
+	if !sameSide(l, e, n) {
+		return colour.New(0, 0, 0)
+	}
+	return b.Albedo.ScalarMult(orenNayar(b.Sigma, l, e, faceForward(n, e)) / math.Pi)
+}
+
+// orenNayar returns the factor by which the Oren-Nayar model with facet angles
+// of standard deviation sigma scales the Lambertian BRDF, for l and e on the
+// side of the normal n.
+func orenNayar(sigma float64, l, e, n vector.Vector) float64 {
+	s2 := sigma * sigma
+	a := 1 - 0.5*s2/(s2+0.33)
+	b := 0.45 * s2 / (s2 + 0.09)
+	cosL, cosE := vector.DotProduct(l, n), vector.DotProduct(e, n)
+	sinL := math.Sqrt(math.Max(0, 1-cosL*cosL))
+	sinE := math.Sqrt(math.Max(0, 1-cosE*cosE))
+	// The cosine of the azimuth between l and e, from their projections onto
+	// the surface.
+	cosPhi := 0.0
+	if sinL > 1e-4 && sinE > 1e-4 {
+		lt := vector.Subtract(l, n.ScalarMultiply(cosL))
+		et := vector.Subtract(e, n.ScalarMultiply(cosE))
+		cosPhi = math.Max(0, vector.DotProduct(lt, et)/(sinL*sinE))
+	}
+	// sinAlpha*tanBeta with alpha the larger angle and beta the smaller.
+	var sinAlpha, tanBeta float64
+	if cosL < cosE {
+		sinAlpha, tanBeta = sinL, sinE/cosE
+	} else {
+		sinAlpha, tanBeta = sinE, sinL/cosL
+	}
+	return a + b*cosPhi*sinAlpha*tanBeta
+}
+
+// Sample chooses a direction with a cosine weighted distribution.
+func (b OrenNayar) Sample(e, n vector.Vector, u0, u1, u2 float64) Sample {
+	return sampleFrom(b, sampling.CosineHemisphere(faceForward(n, e), u1, u2), e, n)
+}
+
+// PDF returns the probability density of Sample choosing l.
+func (b OrenNayar) PDF(l, e, n vector.Vector) float64 {
+	return cosinePDF(l, e, n)
+}
+
+// cosinePDF returns the probability density of choosing l with a cosine
+// weighted distribution on the side of the surface facing e.
+func cosinePDF(l, e, n vector.Vector) float64 {
+	if !sameSide(l, e, n) {
+		return 0
+	}
+	return sampling.CosineHemispherePDF(math.Abs(vector.DotProduct(l, n)))
+}
@@ -0,0 +1,55 @@
+package bsdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestLambert(t *testing.T) {
+	b := Lambert{Albedo: colour.New(0.5, 0.25, 1)}
+	result := b.Eval(up, oblique, up)
+	expected := colour.New(0.5/math.Pi, 0.25/math.Pi, 1/math.Pi)
+	if result.Equal(expected) != true {
+		t.Errorf("Lambert eval was %v, expected %v.", result, expected)
+	}
+	if result := b.Eval(up.Negate(), oblique, up); result.Equal(colour.New(0, 0, 0)) != true {
+		t.Errorf("Lambert eval through the surface was %v, expected black.", result)
+	}
+	checkAlbedo(t, b, oblique, up, 0.5)
+	// From inside the object the surface faces the other way.
+	checkAlbedo(t, b, oblique.Negate(), up, 0.5)
+}
+
+func TestOrenNayar(t *testing.T) {
+	var tests = []struct {
+		sigma    float64
+		l, e     vector.Vector
+		expected float64
+	}{
+		{
+			// With no roughness it is Lambertian.
+			sigma: 0, l: oblique, e: up, expected: 1 / math.Pi,
+		},
+		{
+			// Rough surfaces are flatter head on.
+			sigma: 1, l: up, e: up, expected: (1 - 0.5/1.33) / math.Pi,
+		},
+		{
+			// And brighter back towards the light.
+			sigma: 1, l: oblique, e: oblique,
+			expected: (1 - 0.5/1.33 + 0.45/1.09*math.Sqrt2/2) / math.Pi,
+		},
+	}
+	for _, test := range tests {
+		b := OrenNayar{Albedo: colour.New(1, 1, 1), Sigma: test.sigma}
+		result := b.Eval(test.l, test.e, up).Red
+		if math.Abs(result-test.expected) > 1e-5 {
+			t.Errorf("Oren-Nayar with sigma %v from %v to %v was %v, expected %v.",
+				test.sigma, test.l, test.e, result, test.expected)
+		}
+	}
+	checkAlbedo(t, OrenNayar{Albedo: colour.New(1, 1, 1), Sigma: 0.5}, oblique, up, -1)
+}
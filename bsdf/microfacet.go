@@ -0,0 +1,240 @@
+package bsdf
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// minAlpha keeps the GGX distribution finite for perfectly smooth surfaces.
+const minAlpha = 0.001
+
+// Alpha returns the GGX width for a perceptual roughness.
+func Alpha(roughness float64) float64 {
+	return math.Max(minAlpha, roughness*roughness)
+}
+
+// GGX returns the GGX (Trowbridge-Reitz) distribution of microfacet normals at
+// cosine nDotH to the surface normal.
+func GGX(nDotH, alpha float64) float64 {
+	if nDotH <= 0 {
+		return 0
+	}
+	a2 := alpha * alpha
+	d := nDotH*nDotH*(a2-1) + 1
+	return a2 / (math.Pi * d * d)
+}
+
+// SmithG1 returns the fraction of microfacets visible from a direction at cosine
+// nDotV to the surface normal, for the GGX distribution.
+func SmithG1(nDotV, alpha float64) float64 {
+	if nDotV <= 0 {
+		return 0
+	}
+	a2 := alpha * alpha
+	return 2 * nDotV / (nDotV + math.Sqrt(a2+(1-a2)*nDotV*nDotV))
+}
+
+// SchlickFresnel returns Schlick's approximation of the reflectance of a surface
+// with reflectance f0 at normal incidence, at cosTheta to its normal.
+func SchlickFresnel(f0 colour.Colour, cosTheta float64) colour.Colour {
+	f := math.Pow(1-math.Max(0, math.Min(1, cosTheta)), 5)
+	return f0.Add(colour.New(1, 1, 1).Sub(f0).ScalarMult(f))
+}
+
+// lobe is a GGX distribution of microfacet normals, with widths alphaT along
+// the tangent and alphaB along the bitangent. The tangent may be left empty for
+// an isotropic lobe.
+type lobe struct {
+	alphaT, alphaB float64
+	tangent        vector.Vector
+}
+
+// isotropic returns a lobe with the same width in every direction.
+func isotropic(roughness float64) lobe {
+	a := Alpha(roughness)
+	return lobe{alphaT: a, alphaB: a}
+}
+
+// anisotropic returns a lobe with the roughness along a tangent and its
+// bitangent. When bitangentRoughness is zero the lobe is isotropic.
+func anisotropic(roughness, bitangentRoughness float64, tangent vector.Vector) lobe {
+	if bitangentRoughness == 0 {
+		return isotropic(roughness)
+	}
+	return lobe{alphaT: Alpha(roughness), alphaB: Alpha(bitangentRoughness), tangent: tangent}
+}
+
+// frame returns the tangent and bitangent of the lobe about the normal n.
+func (g lobe) frame(n vector.Vector) (vector.Vector, vector.Vector) {
+	if g.alphaT != g.alphaB {
+		t := vector.Subtract(g.tangent, n.ScalarMultiply(vector.DotProduct(g.tangent, n)))
+		if t.Magnitude() > 1e-6 {
+			t = t.Normalize()
+			return t, vector.CrossProduct(n, t)
+		}
+	}
+	return vector.Basis(n)
+}
+
+// d returns the density of microfacets with normal h about the normal n.
+func (g lobe) d(h, n vector.Vector) float64 {
+	t, b := g.frame(n)
+	z := vector.DotProduct(h, n)
+	if z <= 0 {
+		return 0
+	}
+	x, y := vector.DotProduct(h, t)/g.alphaT, vector.DotProduct(h, b)/g.alphaB
+	k := x*x + y*y + z*z
+	return 1 / (math.Pi * g.alphaT * g.alphaB * k * k)
+}
+
+// g1 returns the fraction of microfacets visible from the direction v.
+func (g lobe) g1(v, n vector.Vector) float64 {
+	t, b := g.frame(n)
+	z := vector.DotProduct(v, n)
+	if z <= 0 {
+		return 0
+	}
+	x, y := vector.DotProduct(v, t)*g.alphaT, vector.DotProduct(v, b)*g.alphaB
+	return 2 * z / (z + math.Sqrt(x*x+y*y+z*z))
+}
+
+// specular returns the GGX specular BRDF with Schlick Fresnel for light from l
+// seen from e, about a normal n facing e, and the Fresnel term.
+func (g lobe) specular(f0 colour.Colour, l, e, n vector.Vector) (colour.Colour, colour.Colour) {
+	nDotL, nDotE := vector.DotProduct(n, l), vector.DotProduct(n, e)
+	if nDotL <= 0 || nDotE <= 0 {
+		return colour.New(0, 0, 0), colour.New(0, 0, 0)
+	}
+	h := vector.Add(l, e)
+	h = h.Normalize()
+	f := SchlickFresnel(f0, vector.DotProduct(e, h))
+	d := g.d(h, n) * g.g1(l, n) * g.g1(e, n)
+	return f.ScalarMult(d / (4 * nDotL * nDotE)), f
+}
+
+// sample returns the reflection of e about a microfacet normal chosen from the
+// lobe about n, by stretching a sample of the lobe with unit widths.
+func (g lobe) sample(e, n vector.Vector, u1, u2 float64) vector.Vector {
+	t, b := g.frame(n)
+	tanTheta := math.Sqrt(u1 / (1 - u1))
+	phi := 2 * math.Pi * u2
+	x := t.ScalarMultiply(g.alphaT * tanTheta * math.Cos(phi))
+	y := b.ScalarMultiply(g.alphaB * tanTheta * math.Sin(phi))
+	h := vector.Add(vector.Add(x, y), n)
+	return reflect(e, h.Normalize())
+}
+
+// pdf returns the probability density of sample choosing l.
+func (g lobe) pdf(l, e, n vector.Vector) float64 {
+	if vector.DotProduct(n, l) <= 0 {
+		return 0
+	}
+	h := vector.Add(l, e)
+	h = h.Normalize()
+	eDotH := vector.DotProduct(e, h)
+	if eDotH <= 0 {
+		return 0
+	}
+	return g.d(h, n) * vector.DotProduct(n, h) / (4 * eDotH)
+}
+
+// Conductor is a GGX microfacet metal with reflectance F0 at normal incidence.
+// When BitangentRoughness is more than zero it is anisotropic, with Roughness
+// along Tangent and BitangentRoughness across it.
+type Conductor struct {
+	F0                            colour.Colour
+	Roughness, BitangentRoughness float64
+	Tangent                       vector.Vector
+}
+
+func (b Conductor) lobe() lobe {
+	return anisotropic(b.Roughness, b.BitangentRoughness, b.Tangent)
+}
+
+// Eval returns the light scattered towards e from l.
+func (b Conductor) Eval(l, e, n vector.Vector) colour.Colour {
+	specular, _ := b.lobe().specular(b.F0, l, e, faceForward(n, e))
+	return specular
+}
+
+// Sample chooses a direction from the distribution of microfacet normals.
+func (b Conductor) Sample(e, n vector.Vector, u0, u1, u2 float64) Sample {
+	return sampleFrom(b, b.lobe().sample(e, faceForward(n, e), u1, u2), e, n)
+}
+
+// PDF returns the probability density of Sample choosing l.
+func (b Conductor) PDF(l, e, n vector.Vector) float64 {
+	return b.lobe().pdf(l, e, faceForward(n, e))
+}
+
+// WithTangent returns the conductor with its highlight stretched along t.
+func (b Conductor) WithTangent(t vector.Vector) BSDF {
+	b.Tangent = t
+	return b
+}
+
+// NeedsTangent returns true if the conductor is anisotropic.
+func (b Conductor) NeedsTangent() bool {
+	return b.BitangentRoughness != 0
+}
+
+// MetallicRoughness is the physically based model of real-time engines, which
+// blends a conductor with a diffuse dielectric by Metallic. When
+// BitangentRoughness is more than zero its highlight is anisotropic, with
+// Roughness along Tangent and BitangentRoughness across it.
+type MetallicRoughness struct {
+	Colour                                  colour.Colour
+	Metallic, Roughness, BitangentRoughness float64
+	Tangent                                 vector.Vector
+}
+
+func (b MetallicRoughness) lobe() lobe {
+	return anisotropic(b.Roughness, b.BitangentRoughness, b.Tangent)
+}
+
+// WithTangent returns the material with its highlight stretched along t.
+func (b MetallicRoughness) WithTangent(t vector.Vector) BSDF {
+	b.Tangent = t
+	return b
+}
+
+// NeedsTangent returns true if the material is anisotropic.
+func (b MetallicRoughness) NeedsTangent() bool {
+	return b.BitangentRoughness != 0
+}
+
+// Eval returns the light scattered towards e from l.
+func (b MetallicRoughness) Eval(l, e, n vector.Vector) colour.Colour {
+	n = faceForward(n, e)
+	f0 := colour.New(0.04, 0.04, 0.04).ScalarMult(1 - b.Metallic).Add(b.Colour.ScalarMult(b.Metallic))
+	specular, f := b.lobe().specular(f0, l, e, n)
+	if vector.DotProduct(l, n) <= 0 || vector.DotProduct(e, n) <= 0 {
+		return specular
+	}
+	diffuse := colour.New(1, 1, 1).Sub(f).Mult(b.Colour).ScalarMult((1 - b.Metallic) / math.Pi)
+	return diffuse.Add(specular)
+}
+
+// specularChance returns the probability of sampling the specular lobe.
+func (b MetallicRoughness) specularChance() float64 {
+	return 0.5 + 0.5*b.Metallic
+}
+
+// Sample chooses a direction from the specular or diffuse lobe by u0.
+func (b MetallicRoughness) Sample(e, n vector.Vector, u0, u1, u2 float64) Sample {
+	facing := faceForward(n, e)
+	if u0 < b.specularChance() {
+		return sampleFrom(b, b.lobe().sample(e, facing, u1, u2), e, n)
+	}
+	return sampleFrom(b, sampling.CosineHemisphere(facing, u1, u2), e, n)
+}
+
+// PDF returns the probability density of Sample choosing l.
+func (b MetallicRoughness) PDF(l, e, n vector.Vector) float64 {
+	p := b.specularChance()
+	return p*b.lobe().pdf(l, e, faceForward(n, e)) + (1-p)*cosinePDF(l, e, n)
+}
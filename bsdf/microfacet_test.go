@@ -0,0 +1,165 @@
+package bsdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestMicrofacetTerms(t *testing.T) {
+	var tests = []struct {
+		name             string
+		result, expected float64
+	}{
+		{name: "GGX(1, 1)", result: GGX(1, 1), expected: 1 / math.Pi},
+		{name: "GGX(-0.5, 0.5)", result: GGX(-0.5, 0.5), expected: 0},
+		{name: "GGX(1, 0.5)", result: GGX(1, 0.5), expected: 4 / math.Pi},
+		{name: "SmithG1(1, 0.5)", result: SmithG1(1, 0.5), expected: 1},
+		{name: "SmithG1(0, 0.5)", result: SmithG1(0, 0.5), expected: 0},
+		{name: "SmithG1(0.5, 1)", result: SmithG1(0.5, 1), expected: 2.0 / 3},
+		{
+			name:     "SchlickFresnel(0.04, 1)",
+			result:   SchlickFresnel(colour.New(0.04, 0.04, 0.04), 1).Red,
+			expected: 0.04,
+		},
+		{
+			name:     "SchlickFresnel(0.04, 0)",
+			result:   SchlickFresnel(colour.New(0.04, 0.04, 0.04), 0).Red,
+			expected: 1,
+		},
+		{name: "Alpha(0.5)", result: Alpha(0.5), expected: 0.25},
+		{name: "Alpha(0)", result: Alpha(0), expected: minAlpha},
+	}
+	for _, test := range tests {
+		if comparison.EpsilonEqual(test.result, test.expected) != true {
+			t.Errorf("%s was %v, expected %v.", test.name, test.result, test.expected)
+		}
+	}
+}
+
+func TestMetallicRoughness(t *testing.T) {
+	b := MetallicRoughness{Colour: colour.New(1, 1, 1), Metallic: 0, Roughness: 1}
+	// Head on, a rough white dielectric reflects 0.04 specularly and the rest of
+	// its light diffusely.
+	result := b.Eval(up, up, up).ScalarMult(math.Pi)
+	expected := colour.New(0.97, 0.97, 0.97)
+	if result.Equal(expected) != true {
+		t.Errorf("Head on metallic roughness eval was %v, expected %v.", result, expected)
+	}
+	if result := b.Eval(up.Negate(), up, up); result.Equal(colour.New(0, 0, 0)) != true {
+		t.Errorf("Metallic roughness eval through the surface was %v, expected black.", result)
+	}
+	checkAlbedo(t, MetallicRoughness{Colour: colour.New(1, 1, 1), Roughness: 0.8}, oblique, up, -1)
+	checkAlbedo(t, MetallicRoughness{Colour: colour.New(1, 1, 1), Metallic: 1, Roughness: 0.7}, oblique, up, -1)
+}
+
+func TestConductor(t *testing.T) {
+	b := Conductor{F0: colour.New(1, 0.5, 0.2), Roughness: 1}
+	// Head on, alpha one and no masking give D/4.
+	result := b.Eval(up, up, up)
+	expected := colour.New(0.25/math.Pi, 0.125/math.Pi, 0.05/math.Pi)
+	if result.Equal(expected) != true {
+		t.Errorf("Head on conductor eval was %v, expected %v.", result, expected)
+	}
+	checkAlbedo(t, Conductor{F0: colour.New(1, 1, 1), Roughness: 0.7}, oblique, up, -1)
+}
+
+func TestLobe(t *testing.T) {
+	// An isotropic lobe is the GGX distribution.
+	g := isotropic(0.7)
+	h := vector.NewVector(0, math.Sqrt(0.75), 0.5)
+	if comparison.EpsilonEqual(g.d(h, up), GGX(math.Sqrt(0.75), Alpha(0.7))) != true {
+		t.Errorf("Isotropic lobe density was %v, expected %v.", g.d(h, up), GGX(math.Sqrt(0.75), Alpha(0.7)))
+	}
+	if comparison.EpsilonEqual(g.g1(oblique, up), SmithG1(math.Sqrt2/2, Alpha(0.7))) != true {
+		t.Errorf("Isotropic lobe masking was %v, expected %v.", g.g1(oblique, up), SmithG1(math.Sqrt2/2, Alpha(0.7)))
+	}
+	// An anisotropic lobe is wider along its rougher direction.
+	a := anisotropic(0.8, 0.2, vector.NewVector(1, 0, 0))
+	alongTangent := vector.NewVector(0.2, 1, 0)
+	alongBitangent := vector.NewVector(0, 1, 0.2)
+	alongTangent, alongBitangent = alongTangent.Normalize(), alongBitangent.Normalize()
+	if a.d(alongTangent, up) <= a.d(alongBitangent, up) {
+		t.Errorf(
+			"Anisotropic lobe density was %v along its tangent and %v along its bitangent.",
+			a.d(alongTangent, up), a.d(alongBitangent, up),
+		)
+	}
+	if result := anisotropic(0.8, 0, vector.NewVector(1, 0, 0)); result != isotropic(0.8) {
+		t.Errorf("Lobe with no bitangent roughness was %+v, expected it to be isotropic.", result)
+	}
+}
+
+func TestAnisotropicConductor(t *testing.T) {
+	b := Conductor{
+		F0: colour.New(1, 1, 1), Roughness: 0.9, BitangentRoughness: 0.5,
+		Tangent: vector.NewVector(1, 0, 0),
+	}
+	checkAlbedo(t, b, oblique, up, -1)
+	// Turning the tangent swaps the highlight's width.
+	l := vector.NewVector(0.3, 1, 0)
+	l = l.Normalize()
+	turned := b.WithTangent(vector.NewVector(0, 0, 1))
+	if b.Eval(l, up, up).Red <= turned.Eval(l, up, up).Red {
+		t.Errorf(
+			"Highlight along the tangent was %v, expected more than %v across it.",
+			b.Eval(l, up, up).Red, turned.Eval(l, up, up).Red,
+		)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	tangent := vector.NewVector(0, 0, 1)
+	conductor := Conductor{F0: colour.New(1, 1, 1), Roughness: 0.5, BitangentRoughness: 0.1}
+	oriented := conductor
+	oriented.Tangent = tangent
+	lambert := Lambert{Albedo: colour.New(1, 1, 1)}
+	var tests = []struct {
+		bsdf, expected BSDF
+	}{
+		{bsdf: lambert, expected: lambert},
+		{bsdf: conductor, expected: oriented},
+		{
+			bsdf:     Mix{A: lambert, B: conductor, Amount: 0.5},
+			expected: Mix{A: lambert, B: oriented, Amount: 0.5},
+		},
+		{bsdf: NewClearCoat(conductor, 1.5, 0), expected: NewClearCoat(oriented, 1.5, 0)},
+		{
+			bsdf:     MetallicRoughness{Roughness: 0.5, BitangentRoughness: 0.1},
+			expected: MetallicRoughness{Roughness: 0.5, BitangentRoughness: 0.1, Tangent: tangent},
+		},
+	}
+	for _, test := range tests {
+		if result := Orient(test.bsdf, tangent); result != test.expected {
+			t.Errorf("Orienting %+v gave %+v, expected %+v.", test.bsdf, result, test.expected)
+		}
+	}
+}
+
+func TestNeedsTangent(t *testing.T) {
+	anisotropic := Conductor{F0: colour.New(1, 1, 1), Roughness: 0.5, BitangentRoughness: 0.1}
+	isotropic := Conductor{F0: colour.New(1, 1, 1), Roughness: 0.5}
+	lambert := Lambert{Albedo: colour.New(1, 1, 1)}
+	var tests = []struct {
+		bsdf     BSDF
+		expected bool
+	}{
+		{bsdf: lambert, expected: false},
+		{bsdf: isotropic, expected: false},
+		{bsdf: anisotropic, expected: true},
+		{bsdf: MetallicRoughness{Roughness: 0.5}, expected: false},
+		{bsdf: MetallicRoughness{Roughness: 0.5, BitangentRoughness: 0.1}, expected: true},
+		{bsdf: Mix{A: lambert, B: isotropic, Amount: 0.5}, expected: false},
+		{bsdf: Mix{A: lambert, B: anisotropic, Amount: 0.5}, expected: true},
+		{bsdf: NewClearCoat(isotropic, 1.5, 0), expected: false},
+		{bsdf: NewClearCoat(anisotropic, 1.5, 0), expected: true},
+	}
+	for _, test := range tests {
+		if result := NeedsTangent(test.bsdf); result != test.expected {
+			t.Errorf("NeedsTangent(%+v) was %v, expected %v.", test.bsdf, result, test.expected)
+		}
+	}
+}
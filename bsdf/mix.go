@@ -0,0 +1,80 @@
+package bsdf
+
+import (
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Mix blends two BSDFs, taking Amount of B and the rest of A.
+type Mix struct {
+	A, B   BSDF
+	Amount float64
+}
+
+// Eval returns the blend of the light scattered towards e from l.
+func (b Mix) Eval(l, e, n vector.Vector) colour.Colour {
+	return b.A.Eval(l, e, n).ScalarMult(1 - b.Amount).Add(b.B.Eval(l, e, n).ScalarMult(b.Amount))
+}
+
+// Sample chooses a direction from A or B by u0.
+func (b Mix) Sample(e, n vector.Vector, u0, u1, u2 float64) Sample {
+	var s Sample
+	var chance float64
+	if u0 < 1-b.Amount {
+		chance = 1 - b.Amount
+		s = b.A.Sample(e, n, u0/chance, u1, u2)
+	} else {
+		chance = b.Amount
+		s = b.B.Sample(e, n, (u0-(1-chance))/chance, u1, u2)
+	}
+	if s.Specular {
+		// The chance of choosing the BSDF cancels its share of the light.
+		s.PDF *= chance
+		return s
+	}
+	if s.PDF <= 0 {
+		return s
+	}
+	return sampleFrom(b, s.Direction, e, n)
+}
+
+// PDF returns the probability density of Sample choosing l.
+func (b Mix) PDF(l, e, n vector.Vector) float64 {
+	return b.A.PDF(l, e, n)*(1-b.Amount) + b.B.PDF(l, e, n)*b.Amount
+}
+
+// SpecularSamples returns the specular samples of A and B, weighted by their
+// shares.
+func (b Mix) SpecularSamples(e, n vector.Vector) []Sample {
+	var samples []Sample
+	for _, part := range []struct {
+		bsdf   BSDF
+		amount float64
+	}{{b.A, 1 - b.Amount}, {b.B, b.Amount}} {
+		specular, ok := part.bsdf.(SpecularBSDF)
+		if !ok || part.amount <= 0 {
+			continue
+		}
+		for _, s := range specular.SpecularSamples(e, n) {
+			s.Weight = s.Weight.ScalarMult(part.amount)
+			samples = append(samples, s)
+		}
+	}
+	return samples
+}
+
+// Transmission returns the blend of the transmissions of A and B.
+func (b Mix) Transmission() colour.Colour {
+	return transmission(b.A).ScalarMult(1 - b.Amount).Add(transmission(b.B).ScalarMult(b.Amount))
+}
+
+// WithTangent returns the mix of A and B oriented along t.
+func (b Mix) WithTangent(t vector.Vector) BSDF {
+	b.A, b.B = Orient(b.A, t), Orient(b.B, t)
+	return b
+}
+
+// NeedsTangent returns true if A or B needs the tangent.
+func (b Mix) NeedsTangent() bool {
+	return NeedsTangent(b.A) || NeedsTangent(b.B)
+}
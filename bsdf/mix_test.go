@@ -0,0 +1,61 @@
+package bsdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+)
+
+func TestMix(t *testing.T) {
+	a := Lambert{Albedo: colour.New(1, 0, 0)}
+	b := Conductor{F0: colour.New(0, 1, 1), Roughness: 0.5}
+	m := Mix{A: a, B: b, Amount: 0.25}
+	result := m.Eval(oblique, up, up)
+	expected := a.Eval(oblique, up, up).ScalarMult(0.75).Add(b.Eval(oblique, up, up).ScalarMult(0.25))
+	if result.Equal(expected) != true {
+		t.Errorf("Mix eval was %v, expected %v.", result, expected)
+	}
+	pdf := m.PDF(oblique, up, up)
+	expectedPDF := 0.75*a.PDF(oblique, up, up) + 0.25*b.PDF(oblique, up, up)
+	if comparison.EpsilonEqual(pdf, expectedPDF) != true {
+		t.Errorf("Mix pdf was %v, expected %v.", pdf, expectedPDF)
+	}
+	checkAlbedo(t, Mix{A: Lambert{Albedo: colour.New(1, 1, 1)}, B: b, Amount: 0.5}, oblique, up, -1)
+}
+
+func TestMixSpecular(t *testing.T) {
+	m := Mix{A: Lambert{Albedo: colour.New(1, 1, 1)}, B: NewDielectric(1.5), Amount: 0.5}
+	samples := m.SpecularSamples(up, up)
+	if len(samples) != 2 || samples[0].Weight.Equal(colour.New(0.02, 0.02, 0.02)) != true ||
+		samples[1].Weight.Equal(colour.New(0.48, 0.48, 0.48)) != true {
+		t.Errorf("Mix specular samples were %+v.", samples)
+	}
+	s := m.Sample(up, up, 0.75, 0.5, 0.5)
+	if !s.Specular || comparison.EpsilonEqual(s.PDF, 0.48) != true {
+		t.Errorf("Mix sample from the dielectric was %+v, expected pdf 0.48.", s)
+	}
+	s = m.Sample(up, up, 0.25, 0.5, 0.5)
+	if s.Specular || math.Abs(s.Weight.Red-1) > 1e-5 {
+		t.Errorf("Mix sample from the Lambert was %+v, expected weight 1.", s)
+	}
+}
+
+func TestMixTransmission(t *testing.T) {
+	glass := Dielectric{IOR: 1.5, Tint: colour.New(1, 0.5, 0)}
+	matte := Lambert{Albedo: colour.New(1, 1, 1)}
+	var tests = []struct {
+		mix      Mix
+		expected colour.Colour
+	}{
+		{mix: Mix{A: matte, B: glass, Amount: 0.25}, expected: colour.New(0.25, 0.125, 0)},
+		{mix: Mix{A: glass, B: glass, Amount: 0.25}, expected: colour.New(1, 0.5, 0)},
+		{mix: Mix{A: matte, B: matte, Amount: 0.5}, expected: colour.New(0, 0, 0)},
+	}
+	for _, test := range tests {
+		if result := test.mix.Transmission(); result.Equal(test.expected) != true {
+			t.Errorf("Transmission of %+v was %v, expected %v.", test.mix, result, test.expected)
+		}
+	}
+}
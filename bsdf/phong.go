@@ -0,0 +1,57 @@
+package bsdf
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Phong is the Phong model of the Ray Tracer Challenge, with a diffuse colour
+// and a white highlight. When Roughness is more than zero the diffuse term is
+// Oren-Nayar, with Roughness the standard deviation of its facet angles in
+// radians.
+type Phong struct {
+	Colour                       colour.Colour
+	Diffuse, Specular, Shininess float64
+	Roughness                    float64
+}
+
+// Eval returns the light scattered towards e from l. A light of intensity one
+// shining on the surface from l is reflected as pi times this, times the cosine
+// of l to the normal. The highlight is the normalized Phong lobe, so it reflects
+// at most Specular of the light arriving head on.
+func (b Phong) Eval(l, e, n vector.Vector) colour.Colour {
+	n = faceForward(n, e)
+	if vector.DotProduct(l, n) <= 0 {
+		return colour.New(0, 0, 0)
+	}
+	diffuse := b.Diffuse
+	if b.Roughness > 0 && vector.DotProduct(e, n) > 0 {
+		diffuse *= orenNayar(b.Roughness, l, e, n)
+	}
+	specular := b.Highlight(l, e, n) * (b.Shininess + 2) / (2 * math.Pi)
+	return b.Colour.ScalarMult(diffuse / math.Pi).Add(colour.New(specular, specular, specular))
+}
+
+// Highlight returns the highlight of the Ray Tracer Challenge for light from l,
+// which is Specular times the cosine between e and the reflection of l raised
+// to Shininess.
+func (b Phong) Highlight(l, e, n vector.Vector) float64 {
+	reflectDotEye := vector.DotProduct(reflect(l, faceForward(n, e)), e)
+	if reflectDotEye <= 0 {
+		return 0
+	}
+	return b.Specular * math.Pow(reflectDotEye, b.Shininess)
+}
+
+// Sample chooses a direction with a cosine weighted distribution.
+func (b Phong) Sample(e, n vector.Vector, u0, u1, u2 float64) Sample {
+	return sampleFrom(b, sampling.CosineHemisphere(faceForward(n, e), u1, u2), e, n)
+}
+
+// PDF returns the probability density of Sample choosing l.
+func (b Phong) PDF(l, e, n vector.Vector) float64 {
+	return cosinePDF(l, e, n)
+}
@@ -0,0 +1,75 @@
+package bsdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestPhong(t *testing.T) {
+	b := Phong{Colour: colour.New(1, 0.5, 0), Diffuse: 0.9, Specular: 0.9, Shininess: 200}
+	var tests = []struct {
+		l, e     vector.Vector
+		expected colour.Colour
+	}{
+		{
+			// The eye is in the path of the reflection.
+			l: up, e: up, expected: colour.New(91.8, 91.35, 90.9),
+		},
+		{
+			l: oblique, e: up, expected: colour.New(0.9, 0.45, 0),
+		},
+		{
+			// The light is behind the surface.
+			l: up.Negate(), e: up, expected: colour.New(0, 0, 0),
+		},
+	}
+	for _, test := range tests {
+		result := b.Eval(test.l, test.e, up).ScalarMult(math.Pi)
+		if result.Equal(test.expected) != true {
+			t.Errorf("Phong eval from %v to %v was %v, expected %v.", test.l, test.e, result, test.expected)
+		}
+	}
+	checkAlbedo(t, Phong{Colour: colour.New(1, 1, 1), Diffuse: 0.9}, oblique, up, 0.9)
+	// Head on, the highlight reflects all of its Specular.
+	checkAlbedo(t, Phong{Colour: colour.New(1, 1, 1), Specular: 0.5, Shininess: 10}, up, up, 0.5)
+	checkAlbedo(t, Phong{Colour: colour.New(1, 1, 1), Diffuse: 0.4, Specular: 0.5, Shininess: 10}, oblique, up, -1)
+}
+
+func TestPhongHighlight(t *testing.T) {
+	b := Phong{Specular: 0.9, Shininess: 200}
+	var tests = []struct {
+		l, e     vector.Vector
+		expected float64
+	}{
+		{l: up, e: up, expected: 0.9},
+		{l: oblique, e: up, expected: 0},
+		{l: oblique, e: vector.NewVector(0, math.Sqrt2/2, math.Sqrt2/2), expected: 0.9},
+	}
+	for _, test := range tests {
+		if result := b.Highlight(test.l, test.e, up); math.Abs(result-test.expected) > 1e-5 {
+			t.Errorf("Phong highlight from %v to %v was %v, expected %v.", test.l, test.e, result, test.expected)
+		}
+	}
+}
+
+func TestPhongRoughness(t *testing.T) {
+	b := Phong{Colour: colour.New(1, 1, 1), Diffuse: 1, Roughness: 1}
+	var tests = []struct {
+		l, e     vector.Vector
+		expected float64
+	}{
+		{l: up, e: up, expected: orenNayar(1, up, up, up)},
+		{l: oblique, e: oblique, expected: orenNayar(1, oblique, oblique, up)},
+		// The eye is behind the surface, which is turned towards it.
+		{l: oblique.Negate(), e: up.Negate(), expected: orenNayar(1, up, oblique, up)},
+	}
+	for _, test := range tests {
+		result := b.Eval(test.l, test.e, up).Red * math.Pi
+		if math.Abs(result-test.expected) > 1e-5 {
+			t.Errorf("Rough Phong eval from %v to %v was %v, expected %v.", test.l, test.e, result, test.expected)
+		}
+	}
+}
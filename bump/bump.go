@@ -0,0 +1,122 @@
+// Package bump holds the ways materials perturb the shading normals of
+// surfaces to add detail without adding geometry.
+package bump
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/canvas"
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Map is the interface for ways of perturbing a surface's normal.
+type Map interface {
+	// Perturb returns the shading normal at texture co-ordinates (u, v) on a
+	// surface with the unit normal n, tangent t (the direction of increasing
+	// u) and bitangent b (the direction of increasing v).
+	Perturb(u, v float64, n, t, b vector.Vector) vector.Vector
+}
+
+// Pattern is the interface for heights over texture co-ordinates.
+type Pattern interface {
+	HeightAt(u, v float64) float64
+}
+
+// Height perturbs normals by the slope of a height pattern, found by finite
+// differences Delta apart in u and v. Scale is the height of the bumps for a
+// pattern height of one, where the texture co-ordinates are one unit across.
+type Height struct {
+	Pattern      Pattern
+	Scale, Delta float64
+}
+
+// Perturb returns the normal tilted away from the slope of the pattern.
+func (h Height) Perturb(u, v float64, n, t, b vector.Vector) vector.Vector {
+	delta := h.Delta
+	if delta <= 0 {
+		delta = 1e-3
+	}
+	du := (h.Pattern.HeightAt(u+delta, v) - h.Pattern.HeightAt(u-delta, v)) / (2 * delta)
+	dv := (h.Pattern.HeightAt(u, v+delta) - h.Pattern.HeightAt(u, v-delta)) / (2 * delta)
+	tilt := vector.Add(t.ScalarMultiply(du*h.Scale), b.ScalarMultiply(dv*h.Scale))
+	perturbed := vector.Subtract(n, tilt)
+	return perturbed.Normalize()
+}
+
+// NewHeight returns a height map with a pattern and scale.
+func NewHeight(pattern Pattern, scale float64) Height {
+	return Height{Pattern: pattern, Scale: scale, Delta: 1e-3}
+}
+
+// NormalMap perturbs normals by a tangent space normal map image, whose red,
+// green and blue channels hold the tangent, bitangent and normal components of
+// the normal, mapped from [-1, 1] to [0, 1]. Strength scales the tangent and
+// bitangent components.
+type NormalMap struct {
+	Image    *canvas.Canvas
+	Strength float64
+}
+
+// Perturb returns the normal from the image, or n when there is no image.
+func (m NormalMap) Perturb(u, v float64, n, t, b vector.Vector) vector.Vector {
+	if empty(m.Image) {
+		return n
+	}
+	c := Bilinear(m.Image, u, v)
+	x, y, z := (2*c.Red-1)*m.Strength, (2*c.Green-1)*m.Strength, 2*c.Blue-1
+	perturbed := vector.Add(vector.Add(t.ScalarMultiply(x), b.ScalarMultiply(y)), n.ScalarMultiply(z))
+	if perturbed.Magnitude() == 0 {
+		return n
+	}
+	return perturbed.Normalize()
+}
+
+// NewNormalMap returns a normal map of an image at full strength.
+func NewNormalMap(image *canvas.Canvas) NormalMap {
+	return NormalMap{Image: image, Strength: 1}
+}
+
+// Image is a height pattern from the luminance of an image.
+type Image struct {
+	Image *canvas.Canvas
+}
+
+// HeightAt returns the luminance of the image at (u, v).
+func (i Image) HeightAt(u, v float64) float64 {
+	return Bilinear(i.Image, u, v).Luminance()
+}
+
+// Bilinear returns the colour of an image at texture co-ordinates (u, v), with
+// u across from the left and v up from the bottom, interpolated between the
+// nearest pixels. The image repeats outside [0, 1]. A missing or empty image is
+// black.
+func Bilinear(image *canvas.Canvas, u, v float64) colour.Colour {
+	if empty(image) {
+		return colour.New(0, 0, 0)
+	}
+	x := u*float64(image.Width) - 0.5
+	y := (1-v)*float64(image.Height) - 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	pixel := func(x, y float64) colour.Colour {
+		return image.Pixel(wrap(int(x), image.Width), wrap(int(y), image.Height))
+	}
+	top := pixel(x0, y0).ScalarMult(1 - fx).Add(pixel(x0+1, y0).ScalarMult(fx))
+	bottom := pixel(x0, y0+1).ScalarMult(1 - fx).Add(pixel(x0+1, y0+1).ScalarMult(fx))
+	return top.ScalarMult(1 - fy).Add(bottom.ScalarMult(fy))
+}
+
+// empty returns true if there is no image or it has no pixels.
+func empty(image *canvas.Canvas) bool {
+	return image == nil || image.Width <= 0 || image.Height <= 0
+}
+
+// wrap returns i wrapped into [0, n).
+func wrap(i, n int) int {
+	i %= n
+	if i < 0 {
+		i += n
+	}
+	return i
+}
@@ -0,0 +1,96 @@
+package bump
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/canvas"
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// ramp is a height pattern rising along u.
+type ramp struct{}
+
+func (ramp) HeightAt(u, v float64) float64 {
+	return u
+}
+
+// flat is a height pattern at one height.
+type flat struct{}
+
+func (flat) HeightAt(u, v float64) float64 {
+	return 1
+}
+
+var (
+	normal    = vector.NewVector(0, 1, 0)
+	tangent   = vector.NewVector(1, 0, 0)
+	bitangent = vector.NewVector(0, 0, 1)
+)
+
+func image(colours ...colour.Colour) *canvas.Canvas {
+	c := canvas.New(len(colours), 1)
+	for x, pixel := range colours {
+		c.WritePixel(x, 0, pixel)
+	}
+	return &c
+}
+
+func TestPerturb(t *testing.T) {
+	var tests = []struct {
+		bump     Map
+		expected vector.Vector
+	}{
+		{bump: NewHeight(flat{}, 1), expected: normal},
+		{bump: NewHeight(ramp{}, 1), expected: vector.NewVector(-math.Sqrt2/2, math.Sqrt2/2, 0)},
+		{bump: Height{Pattern: ramp{}, Scale: 1}, expected: vector.NewVector(-math.Sqrt2/2, math.Sqrt2/2, 0)},
+		{bump: NewNormalMap(image(colour.New(0.5, 0.5, 1))), expected: normal},
+		{bump: NewNormalMap(image(colour.New(1, 0.5, 0.5))), expected: tangent},
+		{bump: NewNormalMap(image(colour.New(0.5, 1, 0.5))), expected: bitangent},
+		{
+			bump:     NormalMap{Image: image(colour.New(1, 0.5, 1)), Strength: 0.5},
+			expected: vector.NewVector(1/math.Sqrt(5), 2/math.Sqrt(5), 0),
+		},
+		// Normal maps without an image leave the normal alone.
+		{bump: NormalMap{Strength: 1}, expected: normal},
+		{bump: NewNormalMap(image()), expected: normal},
+		{bump: NewHeight(Image{}, 1), expected: normal},
+	}
+	for _, test := range tests {
+		result := test.bump.Perturb(0.5, 0.5, normal, tangent, bitangent)
+		if !vector.Equal(result, test.expected) {
+			t.Errorf("%+v perturbed the normal to %v, expected %v.", test.bump, result, test.expected)
+		}
+	}
+}
+
+func TestBilinear(t *testing.T) {
+	c := image(colour.New(0, 0, 0), colour.New(1, 1, 1))
+	var tests = []struct {
+		u, v, expected float64
+	}{
+		{u: 0.25, v: 0.5, expected: 0},
+		{u: 0.75, v: 0.5, expected: 1},
+		{u: 0.5, v: 0.5, expected: 0.5},
+		{u: 0.375, v: 0.5, expected: 0.25},
+		// The image repeats.
+		{u: 0, v: 0.5, expected: 0.5},
+		{u: 1.25, v: 0.5, expected: 0},
+	}
+	for _, test := range tests {
+		result := Bilinear(c, test.u, test.v)
+		if !result.Equal(colour.New(test.expected, test.expected, test.expected)) {
+			t.Errorf("Bilinear at (%v, %v) was %v, expected %v.", test.u, test.v, result, test.expected)
+		}
+	}
+	for _, empty := range []*canvas.Canvas{nil, image()} {
+		if result := Bilinear(empty, 0.5, 0.5); !result.Equal(colour.New(0, 0, 0)) {
+			t.Errorf("Bilinear of %v was %v, expected black.", empty, result)
+		}
+	}
+	height := Image{Image: c}.HeightAt(0.5, 0.5)
+	if math.Abs(height-0.5) > 1e-9 {
+		t.Errorf("Image height was %v, expected 0.5.", height)
+	}
+}
@@ -5,28 +5,155 @@ import (
 
 	"github.com/lukeshiner/raytrace/canvas"
 	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/environment"
 	"github.com/lukeshiner/raytrace/matrix"
 	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/sampling"
 	"github.com/lukeshiner/raytrace/vector"
 	"github.com/lukeshiner/raytrace/world"
 )
 
+// Projection is the type for the ways a camera projects the world onto its canvas.
+type Projection int
+
+const (
+	// Perspective rays spread from a point through a view plane.
+	Perspective Projection = iota
+	// Orthographic rays are parallel, through a view plane of a fixed size in
+	// world units.
+	Orthographic
+	// Equirectangular rays cover every direction, with longitude across the
+	// canvas and latitude down it, as used by environment.Map.
+	Equirectangular
+	// Fisheye rays are spread evenly by angle over a circle of FOV radians
+	// filling the shorter side of the canvas.
+	Fisheye
+	// CubeMap rays fill six square faces side by side, looking along +x, -x,
+	// +y, -y, +z and -z in turn.
+	CubeMap
+)
+
+// cubeFaces holds the forward and up directions of each face of a cube map.
+var cubeFaces = [6][2]vector.Vector{
+	{vector.NewVector(1, 0, 0), vector.NewVector(0, 1, 0)},
+	{vector.NewVector(-1, 0, 0), vector.NewVector(0, 1, 0)},
+	{vector.NewVector(0, 1, 0), vector.NewVector(0, 0, 1)},
+	{vector.NewVector(0, -1, 0), vector.NewVector(0, 0, -1)},
+	{vector.NewVector(0, 0, 1), vector.NewVector(0, 1, 0)},
+	{vector.NewVector(0, 0, -1), vector.NewVector(0, 1, 0)},
+}
+
 // Camera holds camera data.
 type Camera struct {
 	HSize, VSize                          int
 	FOV, HalfWidth, HalfHeight, PixelSize float64
 	Transform                             matrix.Matrix
+	Projection                            Projection
+	// Sampler chooses where in each pixel rays are cast, SamplesPerPixel how many.
+	Sampler         sampling.Pattern
+	SamplesPerPixel int
+	// When MaxSamplesPerPixel is more than SamplesPerPixel, further batches of
+	// SamplesPerPixel samples are taken for a pixel, up to MaxSamplesPerPixel,
+	// until the standard error of its mean luminance is at most ErrorThreshold.
+	MaxSamplesPerPixel int
+	ErrorThreshold     float64
+	// Filter reconstructs pixels from the samples around them.
+	Filter canvas.Filter
+	// Integrator finds the colour seen by each sample.
+	Integrator world.Integrator
+	// Aperture is the radius of the lens. When it is more than zero, rays start
+	// on the lens and converge on the plane FocalDistance in front of the camera.
+	Aperture, FocalDistance float64
+	// Blades is the number of aperture blades, which gives polygonal bokeh. When
+	// it is less than three the aperture is a disc.
+	Blades        int
+	BladeRotation float64
+	// EndTransform is the camera's transform at the end of its motion, which
+	// starts at Transform. When it is empty the camera does not move.
+	EndTransform matrix.Matrix
+	// motion holds Transform and EndTransform decomposed by SetEndTransform.
+	motion matrix.Motion
+	// Rays are cast at random times between ShutterOpen and ShutterClose, as
+	// fractions of the way from the start to the end of any motion in the world.
+	ShutterOpen, ShutterClose float64
+	// ShutterSpeed in seconds, FStop and ISO set the exposure of the camera, with
+	// scene luminance in cd/m². When any is zero the exposure is not changed.
+	ShutterSpeed, FStop, ISO float64
+	// FocalLength is in millimetres, for a 36mm wide sensor. When it is zero it
+	// is found from the field of view.
+	FocalLength float64
+	// FrameRate is the number of frames a second, where any motion in the world
+	// takes one frame.
+	FrameRate float64
 }
 
 // SetTransform sets the tranform matrix for the camera.
 func (c *Camera) SetTransform(t matrix.Matrix) {
 	c.Transform = t
+	if c.EndTransform.Width != 0 {
+		c.motion = matrix.NewMotion(c.Transform, c.EndTransform)
+	}
+}
+
+// SetEndTransform sets the tranform matrix for the camera at the end of its motion.
+func (c *Camera) SetEndTransform(t matrix.Matrix) {
+	c.EndTransform = t
+	c.motion = matrix.NewMotion(c.Transform, c.EndTransform)
+}
+
+// TransformAt returns the camera's transform matrix at time, interpolated from
+// Transform at time 0 to EndTransform at time 1.
+func (c Camera) TransformAt(time float64) matrix.Matrix {
+	if c.EndTransform.Width == 0 || time == 0 {
+		return c.Transform
+	}
+	// The transforms may have been set directly, without decomposing them.
+	if !matrix.Equal(c.motion.Start, c.Transform) || !matrix.Equal(c.motion.End, c.EndTransform) {
+		return matrix.Interpolate(c.Transform, c.EndTransform, time)
+	}
+	return c.motion.At(time)
 }
 
 // New returns a new camera instance.
 func New(hSize, vSize int, FOV float64) Camera {
+	c := newCamera(hSize, vSize, math.Tan(FOV/2))
+	c.FOV = FOV
+	return c
+}
+
+// NewOrthographic returns a new orthographic camera whose view is width world
+// units across its longer side.
+func NewOrthographic(hSize, vSize int, width float64) Camera {
+	c := newCamera(hSize, vSize, width/2)
+	c.Projection = Orthographic
+	return c
+}
+
+// NewEquirectangular returns a new camera which sees all 360x180 degrees around it.
+func NewEquirectangular(hSize, vSize int) Camera {
+	c := newCamera(hSize, vSize, 1)
+	c.Projection = Equirectangular
+	return c
+}
+
+// NewFisheye returns a new angular fisheye camera with a field of view of FOV radians.
+func NewFisheye(hSize, vSize int, FOV float64) Camera {
+	c := newCamera(hSize, vSize, 1)
+	c.FOV = FOV
+	c.Projection = Fisheye
+	return c
+}
+
+// NewCubeMap returns a new camera which renders the six faces of a cube map,
+// each faceSize pixels square.
+func NewCubeMap(faceSize int) Camera {
+	c := newCamera(faceSize*6, faceSize, 1)
+	c.Projection = CubeMap
+	return c
+}
+
+func newCamera(hSize, vSize int, halfView float64) Camera {
 	var halfWidth, halfHeight float64
-	halfView := math.Tan(FOV / 2)
 	aspect := float64(hSize) / float64(vSize)
 	if aspect >= 1 {
 		halfWidth = halfView
@@ -37,11 +164,46 @@ func New(hSize, vSize int, FOV float64) Camera {
 	}
 	pixelSize := (halfWidth * 2) / float64(hSize)
 	return Camera{
-		HSize: hSize, VSize: vSize, FOV: FOV, Transform: matrix.IdentityMatrix(4),
+		HSize: hSize, VSize: vSize, Transform: matrix.IdentityMatrix(4),
 		HalfHeight: halfHeight, HalfWidth: halfWidth, PixelSize: pixelSize,
+		Sampler: sampling.Grid{}, SamplesPerPixel: 1, Filter: canvas.Box{R: 0.5},
+		FocalDistance: 1, FrameRate: 24, Integrator: world.Whitted{},
 	}
 }
 
+// SetExposure sets the shutter speed in seconds, f-stop and ISO of the camera.
+// The aperture is set from the f-stop and focal length, taking world units to
+// be metres, and the shutter closes after the shutter speed as a fraction of a
+// frame, or at the end of the frame for longer shutter speeds.
+func (c *Camera) SetExposure(shutterSpeed, fStop, ISO float64) {
+	c.ShutterSpeed, c.FStop, c.ISO = shutterSpeed, fStop, ISO
+	if fStop > 0 {
+		c.Aperture = c.focalLength() / 1000 / (2 * fStop)
+	}
+	if c.FrameRate > 0 {
+		c.ShutterClose = math.Min(1, c.ShutterOpen+shutterSpeed*c.FrameRate)
+	}
+}
+
+// Exposure returns the multiplier applied to rendered colours for the camera's
+// shutter speed, f-stop and ISO, which takes the brightest luminance the
+// settings record without saturating to one.
+func (c Camera) Exposure() float64 {
+	if c.ShutterSpeed <= 0 || c.FStop <= 0 || c.ISO <= 0 {
+		return 1
+	}
+	ev100 := math.Log2(c.FStop * c.FStop / c.ShutterSpeed * 100 / c.ISO)
+	return 1 / (1.2 * math.Pow(2, ev100))
+}
+
+// focalLength returns the focal length of the camera in millimetres.
+func (c Camera) focalLength() float64 {
+	if c.FocalLength > 0 || c.FOV <= 0 {
+		return c.FocalLength
+	}
+	return 18 / math.Tan(c.FOV/2)
+}
+
 // ViewTransform returns the transformation matrix for a camera position.
 func ViewTransform(from, to, up vector.Vector) matrix.Matrix {
 	forward := vector.Subtract(to, from)
@@ -58,31 +220,187 @@ func ViewTransform(from, to, up vector.Vector) matrix.Matrix {
 	return matrix.Multiply(orientation, matrix.TranslationMatrix(-from.X, -from.Y, -from.Z))
 }
 
-// RayForPixel returns the ray from camera to pixel (pX, pY).
+// RayForPixel returns the ray from camera through the centre of pixel (pX, pY).
 func RayForPixel(c Camera, pX, pY int) ray.Ray {
-	xOffset := (float64(pX) + 0.5) * c.PixelSize
-	yOffset := (float64(pY) + 0.5) * c.PixelSize
-	worldX := c.HalfWidth - xOffset
-	worldY := c.HalfHeight - yOffset
-	transform, _ := c.Transform.Invert()
-	pixel := vector.MultiplyMatrixByVector(
-		transform, vector.NewPoint(worldX, worldY, -1))
-	origin := vector.MultiplyMatrixByVector(transform, vector.NewPoint(0, 0, 0))
-	direction := vector.Subtract(pixel, origin)
-	return ray.New(origin, direction.Normalize())
+	return RayForPoint(c, float64(pX)+0.5, float64(pY)+0.5)
+}
+
+// RayForPoint returns the ray from camera through the point (x, y) on the
+// canvas, where pixel (i, j) covers [i, i+1) x [j, j+1).
+func RayForPoint(c Camera, x, y float64) ray.Ray {
+	origin, direction := c.localRay(x, y)
+	lens := c.Projection == Perspective || c.Projection == Orthographic
+	if c.Aperture > 0 && lens {
+		// The ray through the lens centre is undeflected, so every ray through
+		// the lens meets it on the focal plane.
+		focus := vector.Add(origin, direction.ScalarMultiply(c.FocalDistance/-direction.Z))
+		lX, lY := c.lensSample()
+		origin = vector.Add(origin, vector.NewVector(lX*c.Aperture, lY*c.Aperture, 0))
+		direction = vector.Subtract(focus, origin)
+	}
+	time := c.shutterTime()
+	transform, _ := c.TransformAt(time).Invert()
+	origin = vector.MultiplyMatrixByVector(transform, origin)
+	direction = vector.MultiplyMatrixByVector(transform, direction)
+	return ray.NewAtTime(origin, direction.Normalize(), time)
+}
+
+// shutterTime returns a random time while the shutter is open.
+func (c Camera) shutterTime() float64 {
+	if c.ShutterClose <= c.ShutterOpen {
+		return c.ShutterOpen
+	}
+	return c.ShutterOpen + sampling.Float64()*(c.ShutterClose-c.ShutterOpen)
+}
+
+// localRay returns the origin and direction, in camera space, of the ray
+// through the point (x, y) on the canvas for a pinhole camera. Points outside
+// a fisheye's circle give rays on its edge.
+func (c Camera) localRay(x, y float64) (vector.Vector, vector.Vector) {
+	origin := vector.NewPoint(0, 0, 0)
+	worldX := c.HalfWidth - x*c.PixelSize
+	worldY := c.HalfHeight - y*c.PixelSize
+	switch c.Projection {
+	case Orthographic:
+		return vector.NewPoint(worldX, worldY, 0), vector.NewVector(0, 0, -1)
+	case Equirectangular:
+		u, v := x/float64(c.HSize), y/float64(c.VSize)
+		return origin, environment.UVToDirection(u, v)
+	case Fisheye:
+		right, up := c.fisheyePosition(x, y)
+		radius := math.Min(1, math.Hypot(right, up))
+		theta := radius * c.FOV / 2
+		phi := math.Atan2(up, right)
+		// In the left handed camera space, right is along -x.
+		return origin, vector.NewVector(
+			-math.Sin(theta)*math.Cos(phi), math.Sin(theta)*math.Sin(phi), -math.Cos(theta),
+		)
+	case CubeMap:
+		size := float64(c.VSize)
+		face := int(math.Min(5, math.Max(0, math.Floor(x/size))))
+		right := 2*(x-float64(face)*size)/size - 1
+		up := 1 - 2*y/size
+		forward, upward := cubeFaces[face][0], cubeFaces[face][1]
+		rightward := vector.CrossProduct(upward, forward)
+		direction := vector.Add(forward, rightward.ScalarMultiply(right))
+		return origin, vector.Add(direction, upward.ScalarMultiply(up))
+	default:
+		return vector.NewPoint(0, 0, 0), vector.NewVector(worldX, worldY, -1)
+	}
+}
+
+// fisheyePosition returns the position of the point (x, y) on the canvas
+// relative to the fisheye's circle, to the right and up from its centre, where
+// the circle has radius one.
+func (c Camera) fisheyePosition(x, y float64) (float64, float64) {
+	radius := math.Min(float64(c.HSize), float64(c.VSize)) / 2
+	return (x - float64(c.HSize)/2) / radius, (float64(c.VSize)/2 - y) / radius
+}
+
+// inView returns false if the point (x, y) on the canvas sees nothing, because
+// it is outside a fisheye's circle.
+func (c Camera) inView(x, y float64) bool {
+	if c.Projection != Fisheye {
+		return true
+	}
+	right, up := c.fisheyePosition(x, y)
+	return math.Hypot(right, up) <= 1
+}
+
+// lensSample returns a random point on the unit aperture.
+func (c Camera) lensSample() (float64, float64) {
+	if c.Blades >= 3 {
+		return sampling.UniformPolygon(
+			c.Blades, c.BladeRotation, sampling.Float64(), sampling.Float64())
+	}
+	return sampling.UniformDisc(sampling.Float64(), sampling.Float64())
 }
 
 // Render returns a rendered canvas.Canvas for a camera and a world.
 func Render(c Camera, w world.World) canvas.Canvas {
-	var r ray.Ray
-	var col colour.Colour
-	img := canvas.New(c.HSize, c.VSize)
+	image, _ := RenderWithSampleCounts(c, w)
+	return image
+}
+
+// RenderWithSampleCounts returns a rendered canvas.Canvas for a camera and a
+// world, and a debug canvas whose brightness is the number of samples taken in
+// each pixel as a fraction of the most allowed.
+func RenderWithSampleCounts(c Camera, w world.World) (canvas.Canvas, canvas.Canvas) {
+	// A camera which was not made by New may not have these set.
+	if c.Sampler == nil {
+		c.Sampler = sampling.Grid{}
+	}
+	if c.Filter == nil {
+		c.Filter = canvas.Box{R: 0.5}
+	}
+	if c.Integrator == nil {
+		c.Integrator = world.Whitted{}
+	}
+	film := canvas.NewFilm(c.HSize, c.VSize, c.Filter)
+	counts := canvas.New(c.HSize, c.VSize)
+	maxSamples := c.SamplesPerPixel
+	if c.MaxSamplesPerPixel > maxSamples {
+		maxSamples = c.MaxSamplesPerPixel
+	}
+	if maxSamples < 1 {
+		maxSamples = 1
+	}
 	for y := 0; y < c.VSize; y++ {
 		for x := 0; x < c.HSize; x++ {
-			r = RayForPixel(c, x, y)
-			col = world.ColourAt(w, r)
-			img.WritePixel(x, y, col)
+			n := renderPixel(c, w, &film, x, y, maxSamples)
+			v := float64(n) / float64(maxSamples)
+			counts.WritePixel(x, y, colour.New(v, v, v))
+		}
+	}
+	image := film.Canvas()
+	if exposure := c.Exposure(); exposure != 1 {
+		for y := 0; y < c.VSize; y++ {
+			for x := 0; x < c.HSize; x++ {
+				image.WritePixel(x, y, image.Pixel(x, y).ScalarMult(exposure))
+			}
+		}
+	}
+	return image, counts
+}
+
+// renderPixel adds samples for pixel (x, y) to film until it has converged or
+// maxSamples have been taken, and returns the number of samples taken.
+func renderPixel(c Camera, w world.World, film *canvas.Film, x, y, maxSamples int) int {
+	var r ray.Ray
+	var col colour.Colour
+	var sX, sY, delta float64
+	var mean, squares float64
+	n := 0
+	for n < maxSamples {
+		batch := c.SamplesPerPixel
+		if batch < 1 {
+			batch = 1
+		}
+		if n+batch > maxSamples {
+			batch = maxSamples - n
+		}
+		offsets := c.Sampler.Offsets(batch)
+		if n > 0 {
+			// Later batches are shifted so they do not repeat the first.
+			offsets = sampling.Shift(offsets, sampling.Float64(), sampling.Float64())
+		}
+		for _, offset := range offsets {
+			sX, sY = float64(x)+offset.X, float64(y)+offset.Y
+			col = colour.New(0, 0, 0)
+			if c.inView(sX, sY) {
+				r = RayForPoint(c, sX, sY)
+				col = c.Integrator.Radiance(w, r)
+			}
+			film.AddSample(sX, sY, col)
+			// Welford's running variance of the luminance.
+			n++
+			delta = col.Luminance() - mean
+			mean += delta / float64(n)
+			squares += delta * (col.Luminance() - mean)
+		}
+		if n > 1 && math.Sqrt(squares/float64(n-1)/float64(n)) <= c.ErrorThreshold {
+			break
 		}
 	}
-	return img
+	return n
 }
@@ -4,8 +4,16 @@ import (
 	"math"
 	"testing"
 
+	"github.com/lukeshiner/raytrace/canvas"
 	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/environment"
+	"github.com/lukeshiner/raytrace/light"
+	"github.com/lukeshiner/raytrace/material"
 	"github.com/lukeshiner/raytrace/matrix"
+	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/shape"
 	"github.com/lukeshiner/raytrace/vector"
 	"github.com/lukeshiner/raytrace/world"
 )
@@ -178,3 +186,470 @@ func TestRender(t *testing.T) {
 		t.Errorf("Render returned %+v, expected %+v.", result, expected)
 	}
 }
+
+func TestRenderDefaults(t *testing.T) {
+	w := world.Default()
+	c := New(11, 11, math.Pi/2)
+	c.SetTransform(ViewTransform(vector.NewPoint(0, 0, -5), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0)))
+	c.Sampler, c.Filter, c.Integrator = nil, nil, nil
+	image := Render(c, w)
+	expected := colour.New(0.38066, 0.47583, 0.2855)
+	if result := image.Pixel(5, 5); !result.Equal(expected) {
+		t.Errorf("Render without a sampler, filter or integrator returned %+v, expected %+v.", result, expected)
+	}
+	c.SamplesPerPixel = 0
+	image = Render(c, w)
+	if result := image.Pixel(5, 5); !result.Equal(expected) {
+		t.Errorf("Render with no samples per pixel returned %+v, expected %+v.", result, expected)
+	}
+	if image := Render(Camera{}, w); image.Width != 0 || image.Height != 0 {
+		t.Errorf("Render with a zero camera returned a %dx%d canvas.", image.Width, image.Height)
+	}
+}
+
+func TestRenderHiddenFromCamera(t *testing.T) {
+	w := world.Default()
+	w.Objects[0].SetVisibleToCamera(false)
+	c := New(11, 11, math.Pi/2)
+	c.SetTransform(ViewTransform(vector.NewPoint(0, 0, -5), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0)))
+	image := Render(c, w)
+	// The inner sphere is seen, still in the shadow of the outer one.
+	expected := colour.New(0.1, 0.1, 0.1)
+	if result := image.Pixel(5, 5); !result.Equal(expected) {
+		t.Errorf("Render past a hidden sphere returned %+v, expected %+v.", result, expected)
+	}
+}
+
+func TestRayForPoint(t *testing.T) {
+	c := New(201, 101, math.Pi/2)
+	var tests = []struct {
+		x, y     float64
+		expected vector.Vector
+	}{
+		{x: 100.5, y: 50.5, expected: vector.NewVector(0, 0, -1)},
+		{x: 0.5, y: 0.5, expected: vector.NewVector(0.66519, 0.33259, -0.66851)},
+		{x: 0, y: 50.5, expected: vector.NewVector(0.70711, 0, -0.70711)},
+	}
+	for _, test := range tests {
+		r := RayForPoint(c, test.x, test.y)
+		if !vector.Equal(r.Direction, test.expected) {
+			t.Errorf(
+				"RayForPoint(%v, %v) had direction %+v, expected %+v.",
+				test.x, test.y, r.Direction, test.expected,
+			)
+		}
+	}
+}
+
+// edgeWorld returns a world with a single evenly white sphere.
+func edgeWorld() world.World {
+	w := world.New()
+	s := shape.NewSphere()
+	m := material.New()
+	m.Ambient = 1
+	m.Diffuse = 0
+	m.Specular = 0
+	s.SetMaterial(m)
+	w.Objects = []shape.Shape{s}
+	w.Lights = []light.Light{light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, -10))}
+	return w
+}
+
+func TestRenderSupersampled(t *testing.T) {
+	var tests = []struct {
+		sampler          sampling.Pattern
+		samples          int
+		filter           canvas.Filter
+		minimum, maximum float64
+	}{
+		{sampler: sampling.Grid{}, samples: 1, filter: canvas.Box{R: 0.5}, minimum: 1, maximum: 1},
+		{sampler: sampling.Grid{}, samples: 16, filter: canvas.Box{R: 0.5}, minimum: 0.5, maximum: 0.75},
+		{sampler: sampling.Stratified{}, samples: 16, filter: canvas.Box{R: 0.5}, minimum: 0.5, maximum: 0.75},
+		{sampler: sampling.Random{}, samples: 64, filter: canvas.Tent{R: 1}, minimum: 0.4, maximum: 0.9},
+	}
+	for _, test := range tests {
+		c := New(11, 11, math.Pi/2)
+		c.SetTransform(ViewTransform(
+			vector.NewPoint(0, 0, -5), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0),
+		))
+		c.Sampler = test.sampler
+		c.SamplesPerPixel = test.samples
+		c.Filter = test.filter
+		sampling.Seed(1)
+		image := Render(c, edgeWorld())
+		// Pixel (4, 5) straddles the left edge of the sphere.
+		result := image.Pixel(4, 5).Red
+		if result < test.minimum || result > test.maximum {
+			t.Errorf(
+				"Render with %d samples from %T had edge value %v, expected %v to %v.",
+				test.samples, test.sampler, result, test.minimum, test.maximum,
+			)
+		}
+		if image.Pixel(5, 5).Red < 0.99 {
+			t.Errorf("Render had centre pixel %+v, expected white.", image.Pixel(5, 5))
+		}
+	}
+}
+
+func TestRenderAdaptive(t *testing.T) {
+	c := New(11, 11, math.Pi/2)
+	c.SetTransform(ViewTransform(
+		vector.NewPoint(0, 0, -5), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0),
+	))
+	c.Sampler = sampling.Stratified{}
+	c.SamplesPerPixel = 4
+	c.MaxSamplesPerPixel = 64
+	c.ErrorThreshold = 0.01
+	sampling.Seed(1)
+	image, counts := RenderWithSampleCounts(c, edgeWorld())
+	var tests = []struct {
+		x, y     int
+		expected float64
+	}{
+		// Flat pixels converge after the first batch.
+		{x: 5, y: 5, expected: 4.0 / 64},
+		{x: 0, y: 0, expected: 4.0 / 64},
+		// The pixel on the edge of the sphere takes every sample.
+		{x: 4, y: 5, expected: 1},
+	}
+	for _, test := range tests {
+		result := counts.Pixel(test.x, test.y).Red
+		if result != test.expected {
+			t.Errorf(
+				"Sample count for pixel (%d, %d) was %v, expected %v.",
+				test.x, test.y, result, test.expected,
+			)
+		}
+	}
+	edge := image.Pixel(4, 5).Red
+	if edge < 0.5 || edge > 0.75 {
+		t.Errorf("Adaptive render had edge value %v, expected 0.5 to 0.75.", edge)
+	}
+}
+
+// recorder is an integrator which records the rays it is given.
+type recorder struct {
+	rays *[]ray.Ray
+}
+
+func (r recorder) Radiance(w world.World, rr ray.Ray) colour.Colour {
+	*r.rays = append(*r.rays, rr)
+	// Alternate black and white so the pixel never converges.
+	if len(*r.rays)%2 == 0 {
+		return colour.New(1, 1, 1)
+	}
+	return colour.New(0, 0, 0)
+}
+
+func TestRenderAdaptiveBatches(t *testing.T) {
+	c := New(1, 1, math.Pi/2)
+	c.SamplesPerPixel = 4
+	c.MaxSamplesPerPixel = 12
+	c.ErrorThreshold = 0.01
+	var rays []ray.Ray
+	c.Integrator = recorder{rays: &rays}
+	sampling.Seed(1)
+	Render(c, world.New())
+	if len(rays) != 12 {
+		t.Fatalf("Adaptive render cast %d rays, expected 12.", len(rays))
+	}
+	// Each batch of the grid is placed differently.
+	for i := range rays {
+		for j := i + 1; j < len(rays); j++ {
+			if vector.Equal(rays[i].Direction, rays[j].Direction) {
+				t.Errorf("Adaptive render cast rays %d and %d in the same direction.", i, j)
+			}
+		}
+	}
+}
+
+func TestRayForPointThinLens(t *testing.T) {
+	var tests = []struct {
+		aperture, focalDistance float64
+		blades                  int
+	}{
+		{aperture: 0.5, focalDistance: 4, blades: 0},
+		{aperture: 0.2, focalDistance: 10, blades: 6},
+	}
+	sampling.Seed(1)
+	for _, test := range tests {
+		c := New(21, 11, math.Pi/2)
+		c.Aperture = test.aperture
+		c.FocalDistance = test.focalDistance
+		c.Blades = test.blades
+		pinhole := New(21, 11, math.Pi/2)
+		for i := 0; i < 20; i++ {
+			r := RayForPoint(c, 3.5, 2.5)
+			p := RayForPoint(pinhole, 3.5, 2.5)
+			if math.Hypot(r.Origin.X, r.Origin.Y) > test.aperture+comparison.EPSLION ||
+				r.Origin.Z != 0 {
+				t.Errorf("Thin lens ray started at %+v, outside the lens.", r.Origin)
+			}
+			focus := r.Position(-test.focalDistance / r.Direction.Z)
+			expected := p.Position(-test.focalDistance / p.Direction.Z)
+			if vector.Equal(focus, expected) != true {
+				t.Errorf("Thin lens ray met the focal plane at %+v, expected %+v.", focus, expected)
+			}
+		}
+	}
+}
+
+func TestNewOrthographic(t *testing.T) {
+	c := NewOrthographic(200, 100, 4)
+	if c.Projection != Orthographic || c.HalfWidth != 2 || c.HalfHeight != 1 ||
+		c.PixelSize != 0.02 {
+		t.Errorf("NewOrthographic(200, 100, 4) produced %+v.", c)
+	}
+}
+
+func TestRayForPixelOrthographic(t *testing.T) {
+	var tests = []struct {
+		transform                         matrix.Matrix
+		x, y                              int
+		expectedOrigin, expectedDirection vector.Vector
+	}{
+		{
+			// A ray through the centre of the canvas.
+			transform:         matrix.IdentityMatrix(4),
+			x:                 50,
+			y:                 25,
+			expectedOrigin:    vector.NewPoint(-0.02, -0.02, 0),
+			expectedDirection: vector.NewVector(0, 0, -1),
+		},
+		{
+			// A ray through a corner of the canvas is parallel to the centre.
+			transform:         matrix.IdentityMatrix(4),
+			x:                 0,
+			y:                 0,
+			expectedOrigin:    vector.NewPoint(1.98, 0.98, 0),
+			expectedDirection: vector.NewVector(0, 0, -1),
+		},
+		{
+			// A ray when the camera is transformed.
+			transform: ViewTransform(
+				vector.NewPoint(5, 0, 0), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0),
+			),
+			x:                 0,
+			y:                 0,
+			expectedOrigin:    vector.NewPoint(5, 0.98, -1.98),
+			expectedDirection: vector.NewVector(-1, 0, 0),
+		},
+	}
+	for _, test := range tests {
+		c := NewOrthographic(100, 50, 4)
+		c.SetTransform(test.transform)
+		r := RayForPixel(c, test.x, test.y)
+		if !vector.Equal(r.Origin, test.expectedOrigin) ||
+			!vector.Equal(r.Direction, test.expectedDirection) {
+			t.Errorf(
+				"Orthographic RayForPixel(%v, %v) returned %+v, expected ray(%+v, %+v)).",
+				test.x, test.y, r, test.expectedOrigin, test.expectedDirection,
+			)
+		}
+	}
+}
+
+func TestRayForPointPanoramic(t *testing.T) {
+	var tests = []struct {
+		camera   Camera
+		x, y     float64
+		expected vector.Vector
+	}{
+		// Equirectangular rays cover the sphere.
+		{camera: NewEquirectangular(360, 180), x: 180, y: 90, expected: vector.NewVector(0, 0, -1)},
+		{camera: NewEquirectangular(360, 180), x: 270, y: 90, expected: vector.NewVector(-1, 0, 0)},
+		{camera: NewEquirectangular(360, 180), x: 0, y: 90, expected: vector.NewVector(0, 0, 1)},
+		{camera: NewEquirectangular(360, 180), x: 90, y: 0, expected: vector.NewVector(0, 1, 0)},
+		// Fisheye rays are spread by angle from the centre.
+		{camera: NewFisheye(100, 100, math.Pi), x: 50, y: 50, expected: vector.NewVector(0, 0, -1)},
+		{camera: NewFisheye(100, 100, math.Pi), x: 100, y: 50, expected: vector.NewVector(-1, 0, 0)},
+		{camera: NewFisheye(100, 100, math.Pi), x: 50, y: 0, expected: vector.NewVector(0, 1, 0)},
+		{
+			camera:   NewFisheye(200, 100, math.Pi),
+			x:        125,
+			y:        50,
+			expected: vector.NewVector(-math.Sqrt(2)/2, 0, -math.Sqrt(2)/2),
+		},
+		// Cube map faces look along each axis in turn.
+		{camera: NewCubeMap(10), x: 5, y: 5, expected: vector.NewVector(1, 0, 0)},
+		{camera: NewCubeMap(10), x: 15, y: 5, expected: vector.NewVector(-1, 0, 0)},
+		{camera: NewCubeMap(10), x: 25, y: 5, expected: vector.NewVector(0, 1, 0)},
+		{camera: NewCubeMap(10), x: 35, y: 5, expected: vector.NewVector(0, -1, 0)},
+		{camera: NewCubeMap(10), x: 45, y: 5, expected: vector.NewVector(0, 0, 1)},
+		{camera: NewCubeMap(10), x: 55, y: 5, expected: vector.NewVector(0, 0, -1)},
+		{
+			camera:   NewCubeMap(10),
+			x:        60,
+			y:        0,
+			expected: vector.NewVector(-1/math.Sqrt(3), 1/math.Sqrt(3), -1/math.Sqrt(3)),
+		},
+	}
+	for _, test := range tests {
+		r := RayForPoint(test.camera, test.x, test.y)
+		if !vector.Equal(r.Origin, vector.NewPoint(0, 0, 0)) ||
+			!vector.Equal(r.Direction, test.expected) {
+			t.Errorf(
+				"RayForPoint(%v, %v) with projection %v returned %+v, expected direction %+v.",
+				test.x, test.y, test.camera.Projection, r, test.expected,
+			)
+		}
+	}
+}
+
+func TestRenderFisheyeOutsideCircle(t *testing.T) {
+	w := world.New()
+	w.Background = environment.NewSolid(colour.New(1, 1, 1))
+	image := Render(NewFisheye(10, 10, math.Pi), w)
+	if image.Pixel(0, 0).Equal(colour.New(0, 0, 0)) != true {
+		t.Errorf("Fisheye corner pixel was %+v, expected black.", image.Pixel(0, 0))
+	}
+	if image.Pixel(5, 5).Equal(colour.New(1, 1, 1)) != true {
+		t.Errorf("Fisheye centre pixel was %+v, expected white.", image.Pixel(5, 5))
+	}
+}
+
+func TestRayForPointShutter(t *testing.T) {
+	c := New(11, 11, math.Pi/2)
+	c.SetTransform(matrix.TranslationMatrix(0, 0, 0))
+	c.SetEndTransform(matrix.TranslationMatrix(-4, 0, 0))
+	c.ShutterOpen = 0.25
+	c.ShutterClose = 0.75
+	sampling.Seed(1)
+	for i := 0; i < 20; i++ {
+		r := RayForPixel(c, 5, 5)
+		if r.Time < 0.25 || r.Time >= 0.75 {
+			t.Errorf("Ray was cast at %v, outside the shutter interval.", r.Time)
+		}
+		expected := vector.NewPoint(4*r.Time, 0, 0)
+		if vector.Equal(r.Origin, expected) != true {
+			t.Errorf("Ray at time %v started at %+v, expected %+v.", r.Time, r.Origin, expected)
+		}
+	}
+	c.ShutterClose = 0
+	if r := RayForPixel(c, 5, 5); r.Time != 0.25 {
+		t.Errorf("Ray with a closed shutter was cast at %v, expected 0.25.", r.Time)
+	}
+}
+
+func TestTransformAt(t *testing.T) {
+	set := New(11, 11, math.Pi/2)
+	set.SetEndTransform(matrix.TranslationMatrix(3, 0, 0))
+	set.SetTransform(matrix.TranslationMatrix(1, 0, 0))
+	assigned := New(11, 11, math.Pi/2)
+	assigned.Transform = matrix.TranslationMatrix(1, 0, 0)
+	assigned.EndTransform = matrix.TranslationMatrix(3, 0, 0)
+	// The transforms may be changed directly after being set.
+	changed := New(11, 11, math.Pi/2)
+	changed.SetEndTransform(matrix.TranslationMatrix(-3, 0, 0))
+	changed.Transform = matrix.TranslationMatrix(1, 0, 0)
+	changed.EndTransform = matrix.TranslationMatrix(3, 0, 0)
+	expected := matrix.TranslationMatrix(2, 0, 0)
+	for _, c := range []Camera{set, assigned, changed} {
+		if result := c.TransformAt(0.5); matrix.Equal(result, expected) != true {
+			t.Errorf("Camera transform at 0.5 was %+v, expected %+v.", result, expected)
+		}
+	}
+}
+
+func TestRenderMotionBlur(t *testing.T) {
+	w := edgeWorld()
+	// The sphere moves from the centre of view to out of it.
+	w.Objects[0].SetEndTransform(matrix.TranslationMatrix(10, 0, 0))
+	c := New(11, 11, math.Pi/2)
+	c.SetTransform(ViewTransform(
+		vector.NewPoint(0, 0, -5), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0),
+	))
+	c.SamplesPerPixel = 64
+	c.ShutterClose = 1
+	sampling.Seed(1)
+	image := Render(c, w)
+	result := image.Pixel(5, 5).Red
+	if result < 0.05 || result > 0.3 {
+		t.Errorf("Motion blurred centre pixel was %v, expected 0.05 to 0.3.", result)
+	}
+}
+
+func TestExposure(t *testing.T) {
+	var tests = []struct {
+		shutterSpeed, fStop, ISO, expected float64
+	}{
+		{shutterSpeed: 0, fStop: 0, ISO: 0, expected: 1},
+		// One second at f/1 and ISO 100 is EV 0.
+		{shutterSpeed: 1, fStop: 1, ISO: 100, expected: 1 / 1.2},
+		// Sunny 16.
+		{shutterSpeed: 0.01, fStop: 16, ISO: 100, expected: 1 / (1.2 * 25600)},
+		// Each stop halves the exposure.
+		{shutterSpeed: 0.5, fStop: 1, ISO: 100, expected: 1 / 2.4},
+		{shutterSpeed: 1, fStop: 1, ISO: 50, expected: 1 / 2.4},
+		{shutterSpeed: 1, fStop: math.Sqrt(2), ISO: 100, expected: 1 / 2.4},
+	}
+	for _, test := range tests {
+		c := New(10, 10, math.Pi/2)
+		c.SetExposure(test.shutterSpeed, test.fStop, test.ISO)
+		result := c.Exposure()
+		if comparison.EpsilonEqual(result, test.expected) != true {
+			t.Errorf(
+				"Exposure for %vs at f/%v and ISO %v was %v, expected %v.",
+				test.shutterSpeed, test.fStop, test.ISO, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestSetExposure(t *testing.T) {
+	c := New(10, 10, math.Pi/2)
+	c.FocalLength = 50
+	c.SetExposure(1.0/48, 2, 100)
+	if comparison.EpsilonEqual(c.Aperture, 0.0125) != true {
+		t.Errorf("A 50mm lens at f/2 had aperture radius %v, expected 0.0125.", c.Aperture)
+	}
+	if comparison.EpsilonEqual(c.ShutterClose, 0.5) != true {
+		t.Errorf("A 1/48s shutter at 24 frames a second closed at %v, expected 0.5.", c.ShutterClose)
+	}
+	c = New(10, 10, math.Pi/2)
+	c.SetExposure(1.0/48, 9, 100)
+	if comparison.EpsilonEqual(c.Aperture, 0.001) != true {
+		t.Errorf("An 18mm lens at f/9 had aperture radius %v, expected 0.001.", c.Aperture)
+	}
+	c.ShutterOpen = 0.25
+	c.SetExposure(1.0/12, 9, 100)
+	if c.ShutterClose != 1 {
+		t.Errorf("A 1/12s shutter at 24 frames a second closed at %v, expected 1.", c.ShutterClose)
+	}
+}
+
+func TestRenderExposure(t *testing.T) {
+	c := New(11, 11, math.Pi/2)
+	c.SetTransform(ViewTransform(
+		vector.NewPoint(0, 0, -5), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0),
+	))
+	c.ShutterSpeed, c.FStop, c.ISO = 0.5, 1, 100
+	image := Render(c, edgeWorld())
+	expected := colour.New(1/2.4, 1/2.4, 1/2.4)
+	if image.Pixel(5, 5).Equal(expected) != true {
+		t.Errorf("Exposed centre pixel was %+v, expected %+v.", image.Pixel(5, 5), expected)
+	}
+}
+
+func TestRenderIntegrator(t *testing.T) {
+	w := world.New()
+	s := shape.NewSphere()
+	m := material.New()
+	m.Specular = 0
+	s.SetMaterial(m)
+	w.Objects = []shape.Shape{s}
+	w.Background = environment.NewSolid(colour.New(1, 1, 1))
+	c := New(11, 11, math.Pi/2)
+	c.SetTransform(ViewTransform(
+		vector.NewPoint(0, 0, -5), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0),
+	))
+	c.SamplesPerPixel = 400
+	c.Sampler = sampling.Stratified{}
+	c.Integrator = world.NewPathTracer()
+	image := Render(c, w)
+	result := image.Pixel(5, 5)
+	// The sphere reflects its albedo of the sky.
+	if math.Abs(result.Red-0.9) > 0.05 {
+		t.Errorf("Path traced centre pixel was %+v, expected about 0.9.", result)
+	}
+}
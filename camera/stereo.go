@@ -0,0 +1,109 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/canvas"
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/matrix"
+	"github.com/lukeshiner/raytrace/world"
+)
+
+// Convergence is the type for the ways a stereo camera's eyes are aimed.
+type Convergence int
+
+const (
+	// Parallel eyes look straight ahead, converging at infinity.
+	Parallel Convergence = iota
+	// ToeIn eyes are turned inwards to meet at the convergence distance.
+	ToeIn
+)
+
+// StereoLayout is the type for the ways a stereo camera's eyes are combined.
+type StereoLayout int
+
+const (
+	// SideBySide places the left eye to the left of the right eye.
+	SideBySide StereoLayout = iota
+	// TopBottom places the left eye above the right eye.
+	TopBottom
+	// Anaglyph takes red from the left eye and green and blue from the right.
+	Anaglyph
+)
+
+// Stereo holds data for a pair of cameras either side of Camera.
+type Stereo struct {
+	Camera
+	// EyeSeparation is the interocular distance in world units.
+	EyeSeparation       float64
+	Convergence         Convergence
+	ConvergenceDistance float64
+	Layout              StereoLayout
+}
+
+// Eyes returns the cameras for the left and right eyes.
+func (s Stereo) Eyes() (Camera, Camera) {
+	return s.eye(1), s.eye(-1)
+}
+
+// eye returns the camera for the eye on side 1 (left, along the camera's +x) or
+// -1 (right).
+func (s Stereo) eye(side float64) Camera {
+	offset := side * s.EyeSeparation / 2
+	eye := matrix.TranslationMatrix(-offset, 0, 0)
+	if s.Convergence == ToeIn && s.ConvergenceDistance > 0 {
+		angle := -math.Atan(offset / s.ConvergenceDistance)
+		eye = matrix.Multiply(matrix.RotationYMatrix(angle), eye)
+	}
+	c := s.Camera
+	c.SetTransform(matrix.Multiply(eye, s.Transform))
+	if s.EndTransform.Width != 0 {
+		c.SetEndTransform(matrix.Multiply(eye, s.EndTransform))
+	}
+	return c
+}
+
+// NewStereo returns a stereo camera with parallel eyes either side of c,
+// combined side by side.
+func NewStereo(c Camera, eyeSeparation float64) Stereo {
+	return Stereo{Camera: c, EyeSeparation: eyeSeparation}
+}
+
+// RenderStereo returns a canvas.Canvas with both eyes of a stereo camera
+// rendered and combined by its layout.
+func RenderStereo(s Stereo, w world.World) canvas.Canvas {
+	left, right := s.Eyes()
+	return CombineStereo(Render(left, w), Render(right, w), s.Layout)
+}
+
+// CombineStereo returns the left and right eye canvases combined by layout.
+func CombineStereo(left, right canvas.Canvas, layout StereoLayout) canvas.Canvas {
+	var img canvas.Canvas
+	switch layout {
+	case TopBottom:
+		img = canvas.New(left.Width, left.Height*2)
+		for x := 0; x < left.Width; x++ {
+			for y := 0; y < left.Height; y++ {
+				img.WritePixel(x, y, left.Pixel(x, y))
+				img.WritePixel(x, y+left.Height, right.Pixel(x, y))
+			}
+		}
+	case Anaglyph:
+		img = canvas.New(left.Width, left.Height)
+		for x := 0; x < left.Width; x++ {
+			for y := 0; y < left.Height; y++ {
+				l, r := left.Pixel(x, y), right.Pixel(x, y)
+				img.WritePixel(x, y, colour.New(l.Red, r.Green, r.Blue))
+			}
+		}
+	default:
+		img = canvas.New(left.Width*2, left.Height)
+		for x := 0; x < left.Width; x++ {
+			for y := 0; y < left.Height; y++ {
+				img.WritePixel(x, y, left.Pixel(x, y))
+				img.WritePixel(x+left.Width, y, right.Pixel(x, y))
+			}
+		}
+	}
+	return img
+}
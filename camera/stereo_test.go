@@ -0,0 +1,86 @@
+package camera
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/canvas"
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestStereoEyes(t *testing.T) {
+	var tests = []struct {
+		convergence                   Convergence
+		leftDirection, rightDirection vector.Vector
+	}{
+		{
+			// Parallel eyes look the same way.
+			convergence:    Parallel,
+			leftDirection:  vector.NewVector(0, 0, 1),
+			rightDirection: vector.NewVector(0, 0, 1),
+		},
+		{
+			// Toed in eyes meet at the convergence distance.
+			convergence:    ToeIn,
+			leftDirection:  vector.NewVector(0.09950, 0, 0.99504),
+			rightDirection: vector.NewVector(-0.09950, 0, 0.99504),
+		},
+	}
+	for _, test := range tests {
+		c := New(11, 11, math.Pi/2)
+		// Looking along +z, the camera's left is -x.
+		c.SetTransform(ViewTransform(
+			vector.NewPoint(0, 0, -5), vector.NewPoint(0, 0, 0), vector.NewVector(0, 1, 0),
+		))
+		s := NewStereo(c, 1)
+		s.Convergence = test.convergence
+		s.ConvergenceDistance = 5
+		left, right := s.Eyes()
+		l, r := RayForPixel(left, 5, 5), RayForPixel(right, 5, 5)
+		if !vector.Equal(l.Origin, vector.NewPoint(-0.5, 0, -5)) ||
+			!vector.Equal(r.Origin, vector.NewPoint(0.5, 0, -5)) {
+			t.Errorf("Stereo eyes were at %+v and %+v.", l.Origin, r.Origin)
+		}
+		if !vector.Equal(l.Direction, test.leftDirection) ||
+			!vector.Equal(r.Direction, test.rightDirection) {
+			t.Errorf(
+				"Stereo eyes looked along %+v and %+v, expected %+v and %+v.",
+				l.Direction, r.Direction, test.leftDirection, test.rightDirection,
+			)
+		}
+	}
+}
+
+func TestCombineStereo(t *testing.T) {
+	left := canvas.New(2, 1)
+	right := canvas.New(2, 1)
+	left.WritePixel(0, 0, colour.New(1, 0.5, 0.25))
+	right.WritePixel(0, 0, colour.New(0.2, 0.4, 0.6))
+	var tests = []struct {
+		layout        StereoLayout
+		width, height int
+		x, y          int
+		expected      colour.Colour
+	}{
+		{layout: SideBySide, width: 4, height: 1, x: 2, y: 0, expected: colour.New(0.2, 0.4, 0.6)},
+		{layout: TopBottom, width: 2, height: 2, x: 0, y: 1, expected: colour.New(0.2, 0.4, 0.6)},
+		{layout: Anaglyph, width: 2, height: 1, x: 0, y: 0, expected: colour.New(1, 0.4, 0.6)},
+	}
+	for _, test := range tests {
+		img := CombineStereo(left, right, test.layout)
+		if img.Width != test.width || img.Height != test.height {
+			t.Errorf(
+				"Stereo layout %v was %dx%d, expected %dx%d.",
+				test.layout, img.Width, img.Height, test.width, test.height,
+			)
+			continue
+		}
+		if img.Pixel(test.x, test.y).Equal(test.expected) != true {
+			t.Errorf(
+				"Stereo layout %v pixel (%d, %d) was %+v, expected %+v.",
+				test.layout, test.x, test.y, img.Pixel(test.x, test.y), test.expected,
+			)
+		}
+	}
+}
@@ -12,6 +12,8 @@ import (
 type Canvas struct {
 	Width, Height int
 	Pixels        [][]colour.Colour
+	// Output is applied to pixels as they are encoded.
+	Output Output
 }
 
 // WritePixel writes a pixel to the canvas.
@@ -36,9 +38,10 @@ func (c *Canvas) ppmHeader() string {
 func (c *Canvas) ppmColours() string {
 	line := ""
 	colours := ""
+	q := newQuantiser(c.Output.Dither, c.Width)
 	for y := 0; y < c.Height; y++ {
 		for x := 0; x < c.Width; x++ {
-			line += ppmFormatPixel(c.Pixel(x, y)) + " "
+			line += ppmFormatLevels(q.quantise(x, y, c.Output.Apply(c.Pixel(x, y)))) + " "
 		}
 		line = formatPPMLine(line)
 		colours += line
@@ -79,9 +82,17 @@ func clampColour(c float64) int {
 	return newC
 }
 
+// quantiseColour returns the 8 bit levels of c without dithering.
+func quantiseColour(c colour.Colour) [3]int {
+	return [3]int{clampColour(c.Red), clampColour(c.Green), clampColour(c.Blue)}
+}
+
 func ppmFormatPixel(c colour.Colour) string {
-	return fmt.Sprintf(
-		"%d %d %d", clampColour(c.Red), clampColour(c.Green), clampColour(c.Blue))
+	return ppmFormatLevels(quantiseColour(c))
+}
+
+func ppmFormatLevels(rgb [3]int) string {
+	return fmt.Sprintf("%d %d %d", rgb[0], rgb[1], rgb[2])
 }
 
 // New creates a new Canvas.
@@ -94,5 +105,5 @@ func New(width, height int) Canvas {
 		}
 		pixels = append(pixels, row)
 	}
-	return Canvas{width, height, pixels}
+	return Canvas{Width: width, Height: height, Pixels: pixels, Output: NewOutput()}
 }
@@ -0,0 +1,208 @@
+package canvas
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/lukeshiner/raytrace/colour"
+)
+
+// Dither is the type for the ways of hiding banding when colours are quantised
+// to 8 bits.
+type Dither int
+
+const (
+	// NoDither rounds each channel up to the next level.
+	NoDither Dither = iota
+	// Bayer adds an ordered 8x8 Bayer matrix threshold.
+	Bayer
+	// FloydSteinberg diffuses each pixel's quantisation error to its neighbours.
+	FloydSteinberg
+	// BlueNoise adds a threshold from a tiled blue noise texture.
+	BlueNoise
+)
+
+// bayer is the 8x8 Bayer threshold matrix.
+var bayer = [8][8]float64{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// quantiser turns colours into 8 bit channel values. Pixels must be quantised
+// in scanline order.
+type quantiser struct {
+	dither Dither
+	// errors holds the Floyd-Steinberg error diffused into the current row,
+	// nextErrors into the row below.
+	errors, nextErrors [][3]float64
+	row                int
+}
+
+func (q *quantiser) quantise(x, y int, c colour.Colour) [3]int {
+	switch q.dither {
+	case Bayer:
+		return thresholdColour(c, (bayer[y%8][x%8]+0.5)/64)
+	case BlueNoise:
+		noise := blueNoise()
+		return thresholdColour(c, noise[y%blueNoiseSize][x%blueNoiseSize])
+	case FloydSteinberg:
+		return q.diffuse(x, y, c)
+	default:
+		return quantiseColour(c)
+	}
+}
+
+func (q *quantiser) diffuse(x, y int, c colour.Colour) [3]int {
+	for y > q.row {
+		q.errors, q.nextErrors = q.nextErrors, make([][3]float64, len(q.errors))
+		q.row++
+	}
+	var rgb [3]int
+	for i, value := range []float64{c.Red, c.Green, c.Blue} {
+		value = 255*math.Max(0, math.Min(1, value)) + q.errors[x][i]
+		rgb[i] = clampLevel(int(math.Round(value)))
+		e := value - float64(rgb[i])
+		if x+1 < len(q.errors) {
+			q.errors[x+1][i] += e * 7 / 16
+			q.nextErrors[x+1][i] += e * 1 / 16
+		}
+		if x > 0 {
+			q.nextErrors[x-1][i] += e * 3 / 16
+		}
+		q.nextErrors[x][i] += e * 5 / 16
+	}
+	return rgb
+}
+
+func newQuantiser(dither Dither, width int) *quantiser {
+	return &quantiser{
+		dither: dither, errors: make([][3]float64, width), nextErrors: make([][3]float64, width),
+	}
+}
+
+// thresholdColour quantises each channel of c down after adding threshold, in
+// [0, 1), to its level.
+func thresholdColour(c colour.Colour, threshold float64) [3]int {
+	var rgb [3]int
+	for i, value := range []float64{c.Red, c.Green, c.Blue} {
+		rgb[i] = clampLevel(int(math.Floor(255*math.Max(0, math.Min(1, value)) + threshold)))
+	}
+	return rgb
+}
+
+func clampLevel(level int) int {
+	if level >= 255 {
+		return 255
+	}
+	if level <= 0 {
+		return 0
+	}
+	return level
+}
+
+const blueNoiseSize = 64
+
+var blueNoiseOnce sync.Once
+var blueNoiseTexture [][]float64
+
+// blueNoise returns a tileable blue noise threshold texture, made once with the
+// void and cluster method.
+func blueNoise() [][]float64 {
+	blueNoiseOnce.Do(func() {
+		blueNoiseTexture = voidAndCluster(blueNoiseSize, 1.5)
+	})
+	return blueNoiseTexture
+}
+
+// voidAndCluster returns a size x size blue noise texture of thresholds in
+// [0, 1), using Ulichney's void and cluster method with a Gaussian of the given
+// sigma on a torus.
+func voidAndCluster(size int, sigma float64) [][]float64 {
+	n := size * size
+	kernel := make([]float64, n)
+	for dy := 0; dy < size; dy++ {
+		for dx := 0; dx < size; dx++ {
+			x := math.Min(float64(dx), float64(size-dx))
+			y := math.Min(float64(dy), float64(size-dy))
+			kernel[dy*size+dx] = math.Exp(-(x*x + y*y) / (2 * sigma * sigma))
+		}
+	}
+	pattern := make([]bool, n)
+	energy := make([]float64, n)
+	set := func(i int, on bool) {
+		pattern[i] = on
+		sign := 1.0
+		if !on {
+			sign = -1
+		}
+		ix, iy := i%size, i/size
+		for j := range energy {
+			dx := (j%size - ix + size) % size
+			dy := (j/size - iy + size) % size
+			energy[j] += sign * kernel[dy*size+dx]
+		}
+	}
+	// extreme returns the set pixel with the most energy (the tightest cluster)
+	// or the unset pixel with the least (the largest void).
+	extreme := func(on bool) int {
+		best := -1
+		for i := range pattern {
+			if pattern[i] != on {
+				continue
+			}
+			if best < 0 || (on && energy[i] > energy[best]) || (!on && energy[i] < energy[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	// Start with a random pattern of a tenth of the pixels, then move points
+	// from clusters to voids until it is evenly spread.
+	r := rand.New(rand.NewSource(1))
+	ones := n / 10
+	for _, i := range r.Perm(n)[:ones] {
+		set(i, true)
+	}
+	for {
+		cluster := extreme(true)
+		set(cluster, false)
+		void := extreme(false)
+		set(void, true)
+		if void == cluster {
+			break
+		}
+	}
+	prototype := append([]bool{}, pattern...)
+	prototypeEnergy := append([]float64{}, energy...)
+
+	ranks := make([]int, n)
+	for rank := ones - 1; rank >= 0; rank-- {
+		cluster := extreme(true)
+		set(cluster, false)
+		ranks[cluster] = rank
+	}
+	copy(pattern, prototype)
+	copy(energy, prototypeEnergy)
+	for rank := ones; rank < n; rank++ {
+		void := extreme(false)
+		set(void, true)
+		ranks[void] = rank
+	}
+
+	texture := make([][]float64, size)
+	for y := 0; y < size; y++ {
+		texture[y] = make([]float64, size)
+		for x := 0; x < size; x++ {
+			texture[y][x] = (float64(ranks[y*size+x]) + 0.5) / float64(n)
+		}
+	}
+	return texture
+}
@@ -0,0 +1,90 @@
+package canvas
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+)
+
+// ditheredLevels returns the red levels of a flat canvas of value v written
+// with dither d.
+func ditheredLevels(d Dither, v float64) []int {
+	c := New(16, 16)
+	for x := 0; x < c.Width; x++ {
+		for y := 0; y < c.Height; y++ {
+			c.WritePixel(x, y, colour.New(v, v, v))
+		}
+	}
+	c.Output.Dither = d
+	var levels []int
+	for i, field := range strings.Fields(strings.Join(strings.Split(c.ToPPM(), "\n")[3:], " ")) {
+		if i%3 == 0 {
+			level, _ := strconv.Atoi(field)
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+func TestDither(t *testing.T) {
+	var tests = []struct {
+		dither Dither
+		value  float64
+	}{
+		{dither: Bayer, value: 100.25 / 255},
+		{dither: FloydSteinberg, value: 100.25 / 255},
+		{dither: BlueNoise, value: 100.25 / 255},
+		{dither: Bayer, value: 200.75 / 255},
+		{dither: FloydSteinberg, value: 200.75 / 255},
+		{dither: BlueNoise, value: 200.75 / 255},
+	}
+	for _, test := range tests {
+		levels := ditheredLevels(test.dither, test.value)
+		counts := map[int]int{}
+		sum := 0
+		for _, level := range levels {
+			counts[level]++
+			sum += level
+		}
+		if len(counts) != 2 {
+			t.Errorf("Dither %d of %v gave levels %v, expected two.", test.dither, test.value, counts)
+		}
+		mean := float64(sum) / float64(len(levels))
+		if expected := test.value * 255; mean < expected-0.05 || mean > expected+0.05 {
+			t.Errorf("Dither %d of %v had mean level %v, expected %v.", test.dither, test.value, mean, expected)
+		}
+		again := ditheredLevels(test.dither, test.value)
+		for i := range levels {
+			if levels[i] != again[i] {
+				t.Errorf("Dither %d of %v was not deterministic.", test.dither, test.value)
+				break
+			}
+		}
+	}
+}
+
+func TestNoDither(t *testing.T) {
+	for _, level := range ditheredLevels(NoDither, 100.25/255) {
+		if level != 101 {
+			t.Errorf("Undithered level was %d, expected 101.", level)
+			break
+		}
+	}
+}
+
+func TestBlueNoiseRanks(t *testing.T) {
+	noise := blueNoise()
+	n := blueNoiseSize * blueNoiseSize
+	seen := make([]bool, n)
+	for y := range noise {
+		for x := range noise[y] {
+			rank := int(noise[y][x] * float64(n))
+			if seen[rank] {
+				t.Errorf("Blue noise rank %d appears more than once.", rank)
+			}
+			seen[rank] = true
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package canvas
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+)
+
+// Filter is the interface for pixel reconstruction filters.
+type Filter interface {
+	// Radius returns the distance in pixels beyond which the filter is zero.
+	Radius() float64
+	// Weight returns the weight of a sample offset (x, y) pixels from a pixel centre.
+	Weight(x, y float64) float64
+}
+
+// Box weights every sample within its radius equally.
+type Box struct {
+	R float64
+}
+
+// Radius returns the radius of the filter.
+func (b Box) Radius() float64 {
+	return b.R
+}
+
+// Weight returns the weight for a sample offset.
+func (b Box) Weight(x, y float64) float64 {
+	if math.Abs(x) > b.R || math.Abs(y) > b.R {
+		return 0
+	}
+	return 1
+}
+
+// Tent weights samples linearly less with their distance from the pixel centre.
+type Tent struct {
+	R float64
+}
+
+// Radius returns the radius of the filter.
+func (t Tent) Radius() float64 {
+	return t.R
+}
+
+// Weight returns the weight for a sample offset.
+func (t Tent) Weight(x, y float64) float64 {
+	return math.Max(0, t.R-math.Abs(x)) * math.Max(0, t.R-math.Abs(y))
+}
+
+// Gaussian weights samples with a Gaussian of falloff Alpha, shifted to reach
+// zero at its radius.
+type Gaussian struct {
+	R, Alpha float64
+}
+
+// Radius returns the radius of the filter.
+func (g Gaussian) Radius() float64 {
+	return g.R
+}
+
+// Weight returns the weight for a sample offset.
+func (g Gaussian) Weight(x, y float64) float64 {
+	return g.gaussian(x) * g.gaussian(y)
+}
+
+func (g Gaussian) gaussian(d float64) float64 {
+	return math.Max(0, math.Exp(-g.Alpha*d*d)-math.Exp(-g.Alpha*g.R*g.R))
+}
+
+// Mitchell is the Mitchell-Netravali cubic filter. B and C of 1/3 are the
+// recommended values.
+type Mitchell struct {
+	R, B, C float64
+}
+
+// Radius returns the radius of the filter.
+func (m Mitchell) Radius() float64 {
+	return m.R
+}
+
+// Weight returns the weight for a sample offset.
+func (m Mitchell) Weight(x, y float64) float64 {
+	return m.mitchell(x/m.R) * m.mitchell(y/m.R)
+}
+
+func (m Mitchell) mitchell(d float64) float64 {
+	d = math.Abs(2 * d)
+	if d > 2 {
+		return 0
+	}
+	if d > 1 {
+		return ((-m.B-6*m.C)*d*d*d + (6*m.B+30*m.C)*d*d + (-12*m.B-48*m.C)*d +
+			(8*m.B + 24*m.C)) / 6
+	}
+	return ((12-9*m.B-6*m.C)*d*d*d + (-18+12*m.B+6*m.C)*d*d + (6 - 2*m.B)) / 6
+}
+
+// Film accumulates colour samples at arbitrary positions into pixels, weighted
+// by a reconstruction filter.
+type Film struct {
+	Width, Height int
+	Filter        Filter
+	pixels        [][]filmPixel
+}
+
+// filmPixel holds the samples added to a pixel, both weighted by the filter
+// and, for the samples inside the pixel, unweighted.
+type filmPixel struct {
+	sum            colour.Colour
+	weight, spread float64
+	boxSum         colour.Colour
+	count          int
+}
+
+// minWeight is the smallest fraction of the total size of the filter weights on
+// a pixel which they may sum to. Filters with negative lobes, like Mitchell, can
+// cancel out to nearly nothing, and dividing by that would blow the pixel up.
+const minWeight = 1e-3
+
+// AddSample adds a sample of colour c at position (x, y), where pixel (i, j)
+// covers [i, i+1) x [j, j+1).
+func (f *Film) AddSample(x, y float64, c colour.Colour) {
+	if i, j := int(math.Floor(x)), int(math.Floor(y)); i >= 0 && i < f.Width && j >= 0 && j < f.Height {
+		f.pixels[i][j].boxSum = f.pixels[i][j].boxSum.Add(c)
+		f.pixels[i][j].count++
+	}
+	radius := f.Filter.Radius()
+	minX := int(math.Max(0, math.Ceil(x-radius-0.5)))
+	maxX := int(math.Min(float64(f.Width-1), math.Floor(x+radius-0.5)))
+	minY := int(math.Max(0, math.Ceil(y-radius-0.5)))
+	maxY := int(math.Min(float64(f.Height-1), math.Floor(y+radius-0.5)))
+	for i := minX; i <= maxX; i++ {
+		for j := minY; j <= maxY; j++ {
+			weight := f.Filter.Weight(x-float64(i)-0.5, y-float64(j)-0.5)
+			if weight == 0 {
+				continue
+			}
+			p := &f.pixels[i][j]
+			p.sum = p.sum.Add(c.ScalarMult(weight))
+			p.weight += weight
+			p.spread += math.Abs(weight)
+		}
+	}
+}
+
+// Canvas returns the filtered image. Pixels whose filter weights sum to nothing,
+// or nearly nothing, take the mean of the samples inside them instead.
+func (f *Film) Canvas() Canvas {
+	c := New(f.Width, f.Height)
+	for x := 0; x < f.Width; x++ {
+		for y := 0; y < f.Height; y++ {
+			p := f.pixels[x][y]
+			if p.weight > 0 && p.weight >= minWeight*p.spread {
+				c.WritePixel(x, y, p.sum.ScalarMult(1/p.weight))
+			} else if p.count > 0 {
+				c.WritePixel(x, y, p.boxSum.ScalarMult(1/float64(p.count)))
+			}
+		}
+	}
+	return c
+}
+
+// NewFilm returns an empty Film.
+func NewFilm(width, height int, filter Filter) Film {
+	pixels := make([][]filmPixel, width)
+	for x := 0; x < width; x++ {
+		pixels[x] = make([]filmPixel, height)
+	}
+	return Film{Width: width, Height: height, Filter: filter, pixels: pixels}
+}
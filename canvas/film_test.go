@@ -0,0 +1,101 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+)
+
+func TestFilterWeight(t *testing.T) {
+	var tests = []struct {
+		filter   Filter
+		x, y     float64
+		expected float64
+	}{
+		{filter: Box{R: 0.5}, x: 0, y: 0, expected: 1},
+		{filter: Box{R: 0.5}, x: 0.4, y: -0.5, expected: 1},
+		{filter: Box{R: 0.5}, x: 0.6, y: 0, expected: 0},
+		{filter: Tent{R: 1}, x: 0, y: 0, expected: 1},
+		{filter: Tent{R: 1}, x: 0.5, y: 0, expected: 0.5},
+		{filter: Tent{R: 1}, x: 0.5, y: 0.5, expected: 0.25},
+		{filter: Tent{R: 1}, x: 1.5, y: 0, expected: 0},
+		{filter: Gaussian{R: 1.5, Alpha: 2}, x: 1.5, y: 0, expected: 0},
+		{filter: Gaussian{R: 1.5, Alpha: 2}, x: 0, y: 0, expected: 0.97791},
+		{filter: Mitchell{R: 2, B: 1.0 / 3, C: 1.0 / 3}, x: 0, y: 0, expected: 0.79012},
+		{filter: Mitchell{R: 2, B: 1.0 / 3, C: 1.0 / 3}, x: 2, y: 0, expected: 0},
+		{filter: Mitchell{R: 2, B: 1.0 / 3, C: 1.0 / 3}, x: 1.5, y: 0, expected: -0.03086},
+	}
+	for _, test := range tests {
+		result := test.filter.Weight(test.x, test.y)
+		if comparison.EpsilonEqual(result, test.expected) != true {
+			t.Errorf(
+				"%+v weight at (%v, %v) was %v, expected %v.",
+				test.filter, test.x, test.y, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestFilmBox(t *testing.T) {
+	f := NewFilm(2, 2, Box{R: 0.5})
+	f.AddSample(0.25, 0.25, colour.New(1, 0, 0))
+	f.AddSample(0.75, 0.75, colour.New(0, 0, 1))
+	f.AddSample(1.5, 1.5, colour.New(0, 1, 0))
+	c := f.Canvas()
+	var tests = []struct {
+		x, y     int
+		expected colour.Colour
+	}{
+		{x: 0, y: 0, expected: colour.New(0.5, 0, 0.5)},
+		{x: 1, y: 1, expected: colour.New(0, 1, 0)},
+		{x: 1, y: 0, expected: colour.New(0, 0, 0)},
+	}
+	for _, test := range tests {
+		if c.Pixel(test.x, test.y).Equal(test.expected) != true {
+			t.Errorf(
+				"Film pixel (%d, %d) was %+v, expected %+v.",
+				test.x, test.y, c.Pixel(test.x, test.y), test.expected,
+			)
+		}
+	}
+}
+
+func TestFilmSplatsToNeighbours(t *testing.T) {
+	f := NewFilm(3, 1, Tent{R: 1})
+	f.AddSample(1.5, 0.5, colour.New(1, 1, 1))
+	f.AddSample(0.5, 0.5, colour.New(0, 0, 0))
+	c := f.Canvas()
+	// The middle pixel's own sample has weight 1, its neighbour's has none.
+	if c.Pixel(1, 0).Equal(colour.New(1, 1, 1)) != true {
+		t.Errorf("Film middle pixel was %+v, expected white.", c.Pixel(1, 0))
+	}
+	f.AddSample(1, 0.5, colour.New(0, 0, 0))
+	c = f.Canvas()
+	expected := colour.New(2.0/3, 2.0/3, 2.0/3)
+	if c.Pixel(1, 0).Equal(expected) != true {
+		t.Errorf("Film middle pixel was %+v, expected %+v.", c.Pixel(1, 0), expected)
+	}
+}
+
+func TestFilmNonPositiveWeights(t *testing.T) {
+	// Every sample is in the negative lobe of the filter for each pixel it
+	// reaches, so the pixels fall back to the mean of their own samples.
+	f := NewFilm(2, 1, Mitchell{R: 0.8, B: 1.0 / 3, C: 1.0 / 3})
+	f.AddSample(0.01, 0.5, colour.New(0, 0, 0))
+	f.AddSample(0.05, 0.5, colour.New(1, 1, 1))
+	f.AddSample(0.95, 0.5, colour.New(0.5, 0.5, 0.5))
+	c := f.Canvas()
+	var tests = []struct {
+		x        int
+		expected colour.Colour
+	}{
+		{x: 0, expected: colour.New(0.5, 0.5, 0.5)},
+		{x: 1, expected: colour.New(0, 0, 0)},
+	}
+	for _, test := range tests {
+		if result := c.Pixel(test.x, 0); result.Equal(test.expected) != true {
+			t.Errorf("Film pixel (%d, 0) was %+v, expected %+v.", test.x, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package canvas
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/lukeshiner/raytrace/colour"
+)
+
+// ReadHDR reads a Radiance RGBE (.hdr) image into a canvas.
+func ReadHDR(r io.Reader) (Canvas, error) {
+	reader := bufio.NewReader(r)
+	width, height, err := readHDRHeader(reader)
+	if err != nil {
+		return Canvas{}, err
+	}
+	c := New(width, height)
+	scanline := make([]byte, width*4)
+	for y := 0; y < height; y++ {
+		if err := readHDRScanline(reader, scanline); err != nil {
+			return Canvas{}, err
+		}
+		for x := 0; x < width; x++ {
+			c.WritePixel(x, y, rgbeToColour(scanline[x*4:x*4+4]))
+		}
+	}
+	return c, nil
+}
+
+func readHDRHeader(r *bufio.Reader) (int, int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, 0, err
+	}
+	if !strings.HasPrefix(line, "#?") {
+		return 0, 0, errors.New("not a Radiance HDR file")
+	}
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return 0, 0, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return 0, 0, fmt.Errorf("unsupported HDR format %q", line)
+		}
+	}
+	line, err = r.ReadString('\n')
+	if err != nil {
+		return 0, 0, err
+	}
+	var width, height int
+	if _, err := fmt.Sscanf(line, "-Y %d +X %d", &height, &width); err != nil {
+		return 0, 0, fmt.Errorf("unsupported HDR resolution %q", strings.TrimSpace(line))
+	}
+	return width, height, nil
+}
+
+func readHDRScanline(r *bufio.Reader, scanline []byte) error {
+	width := len(scanline) / 4
+	if _, err := io.ReadFull(r, scanline[:4]); err != nil {
+		return err
+	}
+	if width < 8 || width > 0x7fff || scanline[0] != 2 || scanline[1] != 2 ||
+		scanline[2]&0x80 != 0 {
+		// Flat scanline.
+		_, err := io.ReadFull(r, scanline[4:])
+		return err
+	}
+	if int(scanline[2])<<8|int(scanline[3]) != width {
+		return errors.New("HDR scanline width mismatch")
+	}
+	// Run length encoded scanline, stored one component at a time.
+	for component := 0; component < 4; component++ {
+		for x := 0; x < width; {
+			count, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				run := int(count) - 128
+				value, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+run > width {
+					return errors.New("HDR run overflows scanline")
+				}
+				for i := 0; i < run; i++ {
+					scanline[(x+i)*4+component] = value
+				}
+				x += run
+			} else {
+				if count == 0 || x+int(count) > width {
+					return errors.New("invalid HDR run length")
+				}
+				for i := 0; i < int(count); i++ {
+					value, err := r.ReadByte()
+					if err != nil {
+						return err
+					}
+					scanline[(x+i)*4+component] = value
+				}
+				x += int(count)
+			}
+		}
+	}
+	return nil
+}
+
+func rgbeToColour(rgbe []byte) colour.Colour {
+	if rgbe[3] == 0 {
+		return colour.New(0, 0, 0)
+	}
+	f := math.Ldexp(1, int(rgbe[3])-(128+8))
+	return colour.New(float64(rgbe[0])*f, float64(rgbe[1])*f, float64(rgbe[2])*f)
+}
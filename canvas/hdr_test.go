@@ -0,0 +1,61 @@
+package canvas
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+)
+
+func TestReadHDRFlat(t *testing.T) {
+	data := []byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 2\n")
+	data = append(data, 128, 64, 0, 129, 0, 0, 0, 0)
+	c, err := ReadHDR(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadHDR returned error %v.", err)
+	}
+	if c.Width != 2 || c.Height != 1 {
+		t.Fatalf("ReadHDR canvas was %dx%d, expected 2x1.", c.Width, c.Height)
+	}
+	expected := colour.New(1, 0.5, 0)
+	if c.Pixel(0, 0).Equal(expected) != true {
+		t.Errorf("HDR pixel (0, 0) was %+v, expected %+v.", c.Pixel(0, 0), expected)
+	}
+	if c.Pixel(1, 0).Equal(colour.New(0, 0, 0)) != true {
+		t.Errorf("HDR pixel (1, 0) was %+v, expected black.", c.Pixel(1, 0))
+	}
+}
+
+func TestReadHDRRunLength(t *testing.T) {
+	data := []byte("#?RADIANCE\n\n-Y 1 +X 8\n")
+	data = append(data, 2, 2, 0, 8)
+	// Red is a run of 8, green is 8 literals, blue is a run of 8, exponent a run.
+	data = append(data, 128+8, 128)
+	data = append(data, 8, 0, 16, 32, 48, 64, 80, 96, 112)
+	data = append(data, 128+8, 0)
+	data = append(data, 128+8, 129)
+	c, err := ReadHDR(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadHDR returned error %v.", err)
+	}
+	for x := 0; x < 8; x++ {
+		expected := colour.New(1, float64(x)/8, 0)
+		if c.Pixel(x, 0).Equal(expected) != true {
+			t.Errorf("HDR pixel (%d, 0) was %+v, expected %+v.", x, c.Pixel(x, 0), expected)
+		}
+	}
+}
+
+func TestReadHDRInvalid(t *testing.T) {
+	var tests = []string{
+		"P3\n1 1\n255\n",
+		"#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n",
+		"#?RADIANCE\n\n+X 1 -Y 1\n",
+		"#?RADIANCE\n\n-Y 1 +X 1\n\x01",
+	}
+	for _, test := range tests {
+		if _, err := ReadHDR(bytes.NewReader([]byte(test))); err == nil {
+			t.Errorf("ReadHDR(%q) did not return an error.", test)
+		}
+	}
+}
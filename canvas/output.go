@@ -0,0 +1,86 @@
+package canvas
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+)
+
+// ToneMap is the type for operators which compress high dynamic range colours
+// into the displayable range.
+type ToneMap int
+
+const (
+	// Clamp clips each channel to [0, 1].
+	Clamp ToneMap = iota
+	// Reinhard maps each channel x to x / (1 + x).
+	Reinhard
+	// Filmic is John Hable's filmic curve from Uncharted 2.
+	Filmic
+	// ACES is Krzysztof Narkowicz's fit of the ACES filmic curve.
+	ACES
+)
+
+// Transfer is the type for functions which encode linear colours for display.
+type Transfer int
+
+const (
+	// Linear leaves colours unchanged.
+	Linear Transfer = iota
+	// SRGB is the sRGB transfer function.
+	SRGB
+)
+
+// Output holds the settings for turning a canvas's linear colours into the
+// colours written by its encoders. The zero Output writes colours unchanged.
+type Output struct {
+	// Exposure multiplies colours before they are tone mapped. When it is zero
+	// or less colours are not changed.
+	Exposure float64
+	ToneMap  ToneMap
+	Transfer Transfer
+	// Dither is used when colours are quantised to 8 bits.
+	Dither Dither
+}
+
+// Apply returns the colour c as it should be encoded.
+func (o Output) Apply(c colour.Colour) colour.Colour {
+	if o.Exposure > 0 {
+		c = c.ScalarMult(o.Exposure)
+	}
+	return colour.New(o.channel(c.Red), o.channel(c.Green), o.channel(c.Blue))
+}
+
+func (o Output) channel(x float64) float64 {
+	switch o.ToneMap {
+	case Reinhard:
+		x = math.Max(0, x)
+		x = x / (1 + x)
+	case Filmic:
+		const white = 11.2
+		x = hable(2*math.Max(0, x)) / hable(white)
+	case ACES:
+		x = math.Max(0, x)
+		x = math.Min(1, (x*(2.51*x+0.03))/(x*(2.43*x+0.59)+0.14))
+	}
+	if o.Transfer == SRGB {
+		x = math.Max(0, math.Min(1, x))
+		if x <= 0.0031308 {
+			x *= 12.92
+		} else {
+			x = 1.055*math.Pow(x, 1/2.4) - 0.055
+		}
+	}
+	return x
+}
+
+// hable is the curve of the Filmic tone map.
+func hable(x float64) float64 {
+	const a, b, c, d, e, f = 0.15, 0.50, 0.10, 0.20, 0.02, 0.30
+	return (x*(a*x+c*b)+d*e)/(x*(a*x+b)+d*f) - e/f
+}
+
+// NewOutput returns output settings which write colours unchanged.
+func NewOutput() Output {
+	return Output{Exposure: 1, ToneMap: Clamp, Transfer: Linear}
+}
@@ -0,0 +1,90 @@
+package canvas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+)
+
+func TestOutputApply(t *testing.T) {
+	var tests = []struct {
+		output   Output
+		colour   colour.Colour
+		expected colour.Colour
+	}{
+		{
+			// The default output leaves colours unchanged.
+			output:   NewOutput(),
+			colour:   colour.New(0.5, 2, -1),
+			expected: colour.New(0.5, 2, -1),
+		},
+		{
+			// So does the zero output.
+			output:   Output{},
+			colour:   colour.New(0.5, 2, -1),
+			expected: colour.New(0.5, 2, -1),
+		},
+		{
+			output:   Output{Exposure: 2, ToneMap: Clamp, Transfer: Linear},
+			colour:   colour.New(0.25, 0.5, 0),
+			expected: colour.New(0.5, 1, 0),
+		},
+		{
+			output:   Output{Exposure: 1, ToneMap: Reinhard, Transfer: Linear},
+			colour:   colour.New(1, 3, 0),
+			expected: colour.New(0.5, 0.75, 0),
+		},
+		{
+			output:   Output{Exposure: 1, ToneMap: ACES, Transfer: Linear},
+			colour:   colour.New(0, 1, 100),
+			expected: colour.New(0, 0.80380, 1),
+		},
+		{
+			// The filmic curve maps its white point to one.
+			output:   Output{Exposure: 1, ToneMap: Filmic, Transfer: Linear},
+			colour:   colour.New(0, 5.6, 1),
+			expected: colour.New(0, 1, 0.49292),
+		},
+		{
+			output:   Output{Exposure: 1, ToneMap: Clamp, Transfer: SRGB},
+			colour:   colour.New(0, 0.5, 0.002),
+			expected: colour.New(0, 0.73536, 0.02584),
+		},
+		{
+			output:   Output{Exposure: 1, ToneMap: Clamp, Transfer: SRGB},
+			colour:   colour.New(1, 2, -1),
+			expected: colour.New(1, 1, 0),
+		},
+	}
+	for _, test := range tests {
+		result := test.output.Apply(test.colour)
+		if result.Equal(test.expected) != true {
+			t.Errorf(
+				"Output %+v applied to %+v was %+v, expected %+v.",
+				test.output, test.colour, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestPPMUsesOutput(t *testing.T) {
+	c := New(2, 1)
+	c.WritePixel(0, 0, colour.New(0.5, 0.5, 0.5))
+	c.WritePixel(1, 0, colour.New(3, 3, 3))
+	c.Output = Output{Exposure: 1, ToneMap: Reinhard, Transfer: SRGB}
+	lines := strings.Split(c.ToPPM(), "\n")
+	expected := "157 157 157 225 225 225"
+	if lines[3] != expected {
+		t.Errorf("PPM pixel data with output %+v was %q, expected %q.", c.Output, lines[3], expected)
+	}
+}
+
+func TestPPMWithoutNew(t *testing.T) {
+	c := Canvas{Width: 1, Height: 1, Pixels: [][]colour.Colour{{colour.New(0.5, 0, 1)}}}
+	lines := strings.Split(c.ToPPM(), "\n")
+	expected := "128 0 255"
+	if lines[3] != expected {
+		t.Errorf("PPM pixel data of a canvas literal was %q, expected %q.", lines[3], expected)
+	}
+}
@@ -45,3 +45,17 @@ func (c Colour) Mult(other Colour) Colour {
 func New(red, green, blue float64) Colour {
 	return Colour{Red: red, Green: green, Blue: blue}
 }
+
+// Luminance returns the relative luminance of c.
+func (c Colour) Luminance() float64 {
+	return 0.2126*c.Red + 0.7152*c.Green + 0.0722*c.Blue
+}
+
+// FromXYZ returns the linear sRGB colour for CIE XYZ tristimulus values.
+func FromXYZ(x, y, z float64) Colour {
+	return Colour{
+		Red:   3.2406*x - 1.5372*y - 0.4986*z,
+		Green: -0.9689*x + 1.8758*y + 0.0415*z,
+		Blue:  0.0557*x - 0.2040*y + 1.0570*z,
+	}
+}
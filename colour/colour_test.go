@@ -1,7 +1,10 @@
 package colour
 
 import (
+	"math"
 	"testing"
+
+	"github.com/lukeshiner/raytrace/comparison"
 )
 
 func TestColour(t *testing.T) {
@@ -161,3 +164,42 @@ func TestColourMultMethod(t *testing.T) {
 		}
 	}
 }
+
+func TestLuminance(t *testing.T) {
+	var tests = []struct {
+		colour   Colour
+		expected float64
+	}{
+		{colour: New(0, 0, 0), expected: 0},
+		{colour: New(1, 1, 1), expected: 1},
+		{colour: New(0, 1, 0), expected: 0.7152},
+	}
+	for _, test := range tests {
+		result := test.colour.Luminance()
+		if comparison.EpsilonEqual(result, test.expected) != true {
+			t.Errorf("Luminance of %+v was %v, expected %v.", test.colour, result, test.expected)
+		}
+	}
+}
+
+func TestFromXYZ(t *testing.T) {
+	var tests = []struct {
+		x, y, z  float64
+		expected Colour
+	}{
+		{x: 0, y: 0, z: 0, expected: New(0, 0, 0)},
+		// D65 white.
+		{x: 0.95047, y: 1, z: 1.08883, expected: New(1, 1, 1)},
+	}
+	for _, test := range tests {
+		result := FromXYZ(test.x, test.y, test.z)
+		if math.Abs(result.Red-test.expected.Red) > 0.001 ||
+			math.Abs(result.Green-test.expected.Green) > 0.001 ||
+			math.Abs(result.Blue-test.expected.Blue) > 0.001 {
+			t.Errorf(
+				"FromXYZ(%v, %v, %v) was %+v, expected %+v.",
+				test.x, test.y, test.z, result, test.expected,
+			)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package environment
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Environment is the interface for the background surrounding a world.
+type Environment interface {
+	// ColourAt returns the radiance arriving from the given normalized direction.
+	ColourAt(direction vector.Vector) colour.Colour
+	// Sample returns a direction chosen with the random numbers u1 and u2 and
+	// its solid angle probability density.
+	Sample(u1, u2 float64) (vector.Vector, float64)
+	// PDF returns the solid angle probability density of Sample choosing direction.
+	PDF(direction vector.Vector) float64
+}
+
+// Solid is an environment of a single colour.
+type Solid struct {
+	Colour colour.Colour
+}
+
+// ColourAt returns the colour of the environment.
+func (s Solid) ColourAt(direction vector.Vector) colour.Colour {
+	return s.Colour
+}
+
+// Sample returns a direction uniformly distributed over the sphere.
+func (s Solid) Sample(u1, u2 float64) (vector.Vector, float64) {
+	return sampling.UniformSphere(u1, u2), sampling.UniformSpherePDF
+}
+
+// PDF returns the probability density of sampling a direction.
+func (s Solid) PDF(direction vector.Vector) float64 {
+	return sampling.UniformSpherePDF
+}
+
+// NewSolid returns a new Solid environment.
+func NewSolid(c colour.Colour) Solid {
+	return Solid{Colour: c}
+}
+
+// Gradient is an environment which blends vertically from Bottom to Top.
+type Gradient struct {
+	Bottom, Top colour.Colour
+}
+
+// ColourAt returns the colour of the gradient in a direction.
+func (g Gradient) ColourAt(direction vector.Vector) colour.Colour {
+	t := 0.5 * (math.Max(-1, math.Min(1, direction.Y)) + 1)
+	return g.Bottom.ScalarMult(1 - t).Add(g.Top.ScalarMult(t))
+}
+
+// Sample returns a direction uniformly distributed over the sphere.
+func (g Gradient) Sample(u1, u2 float64) (vector.Vector, float64) {
+	return sampling.UniformSphere(u1, u2), sampling.UniformSpherePDF
+}
+
+// PDF returns the probability density of sampling a direction.
+func (g Gradient) PDF(direction vector.Vector) float64 {
+	return sampling.UniformSpherePDF
+}
+
+// NewGradient returns a new Gradient environment.
+func NewGradient(bottom, top colour.Colour) Gradient {
+	return Gradient{Bottom: bottom, Top: top}
+}
+
+// DirectionToUV returns the equirectangular co-ordinates in [0, 1] of a
+// normalized direction. The -z axis is at the centre of the map, +y is at the
+// top and, as the world is left handed, +x is to the left.
+func DirectionToUV(direction vector.Vector) (float64, float64) {
+	u := 0.5 + math.Atan2(-direction.X, -direction.Z)/(2*math.Pi)
+	v := math.Acos(math.Max(-1, math.Min(1, direction.Y))) / math.Pi
+	return u, v
+}
+
+// UVToDirection returns the normalized direction for equirectangular
+// co-ordinates.
+func UVToDirection(u, v float64) vector.Vector {
+	phi := (u - 0.5) * 2 * math.Pi
+	theta := v * math.Pi
+	sinTheta := math.Sin(theta)
+	return vector.NewVector(-sinTheta*math.Sin(phi), math.Cos(theta), -sinTheta*math.Cos(phi))
+}
@@ -0,0 +1,89 @@
+package environment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestSolid(t *testing.T) {
+	c := colour.New(0.2, 0.4, 0.6)
+	e := NewSolid(c)
+	for _, d := range []vector.Vector{vector.NewVector(0, 1, 0), vector.NewVector(1, 0, 0)} {
+		if e.ColourAt(d).Equal(c) != true {
+			t.Errorf("Solid environment colour at %+v was %+v, expected %+v.", d, e.ColourAt(d), c)
+		}
+	}
+	_, pdf := e.Sample(0.3, 0.7)
+	if comparison.EpsilonEqual(pdf, 1/(4*math.Pi)) != true {
+		t.Errorf("Solid environment sample pdf was %v, expected %v.", pdf, 1/(4*math.Pi))
+	}
+}
+
+func TestGradient(t *testing.T) {
+	var tests = []struct {
+		direction vector.Vector
+		expected  colour.Colour
+	}{
+		{direction: vector.NewVector(0, 1, 0), expected: colour.New(0, 0, 1)},
+		{direction: vector.NewVector(0, -1, 0), expected: colour.New(1, 1, 1)},
+		{direction: vector.NewVector(1, 0, 0), expected: colour.New(0.5, 0.5, 1)},
+	}
+	e := NewGradient(colour.New(1, 1, 1), colour.New(0, 0, 1))
+	for _, test := range tests {
+		result := e.ColourAt(test.direction)
+		if result.Equal(test.expected) != true {
+			t.Errorf(
+				"Gradient colour at %+v was %+v, expected %+v.",
+				test.direction, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestDirectionToUV(t *testing.T) {
+	var tests = []struct {
+		direction vector.Vector
+		u, v      float64
+	}{
+		{direction: vector.NewVector(0, 0, -1), u: 0.5, v: 0.5},
+		{direction: vector.NewVector(1, 0, 0), u: 0.25, v: 0.5},
+		{direction: vector.NewVector(-1, 0, 0), u: 0.75, v: 0.5},
+		{direction: vector.NewVector(0, 1, 0), u: 0, v: 0},
+		{direction: vector.NewVector(0, -1, 0), u: 0, v: 1},
+	}
+	for _, test := range tests {
+		u, v := DirectionToUV(test.direction)
+		if comparison.EpsilonEqual(u, test.u) != true || comparison.EpsilonEqual(v, test.v) != true {
+			t.Errorf(
+				"DirectionToUV(%+v) was (%v, %v), expected (%v, %v).",
+				test.direction, u, v, test.u, test.v,
+			)
+		}
+		d := UVToDirection(test.u, test.v)
+		if vector.Equal(d, test.direction) != true {
+			t.Errorf(
+				"UVToDirection(%v, %v) was %+v, expected %+v.", test.u, test.v, d, test.direction,
+			)
+		}
+	}
+}
+
+func TestSampleSolidAngle(t *testing.T) {
+	// Integrating the pdf over the sphere should give one.
+	e := NewGradient(colour.New(0, 0, 0), colour.New(1, 1, 1))
+	const n = 20000
+	sampling.Seed(1)
+	total := 0.0
+	for i := 0; i < n; i++ {
+		d := sampling.UniformSphere(sampling.Float64(), sampling.Float64())
+		total += e.PDF(d) / sampling.UniformSpherePDF
+	}
+	if math.Abs(total/n-1) > 0.01 {
+		t.Errorf("Integral of gradient pdf was %v, expected 1.", total/n)
+	}
+}
@@ -0,0 +1,139 @@
+package environment
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lukeshiner/raytrace/canvas"
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Map is an environment lit by an equirectangular image, usually read from an
+// HDR file. It is importance sampled by luminance.
+type Map struct {
+	Image     canvas.Canvas
+	Intensity float64
+	// rowCDF is the cumulative distribution of choosing each row, columnCDFs
+	// the cumulative distributions of choosing each column within a row.
+	rowCDF     []float64
+	columnCDFs [][]float64
+	// weights holds the normalized probability density of each pixel over
+	// (u, v) space.
+	weights [][]float64
+}
+
+// ColourAt returns the colour of the map in a direction.
+func (m Map) ColourAt(direction vector.Vector) colour.Colour {
+	x, y := m.pixel(DirectionToUV(direction))
+	return m.Image.Pixel(x, y).ScalarMult(m.Intensity)
+}
+
+// Sample returns a direction chosen in proportion to the luminance of the map.
+func (m Map) Sample(u1, u2 float64) (vector.Vector, float64) {
+	y, dv := sampleCDF(m.rowCDF, u1)
+	x, du := sampleCDF(m.columnCDFs[y], u2)
+	u := (float64(x) + du) / float64(m.Image.Width)
+	v := (float64(y) + dv) / float64(m.Image.Height)
+	direction := UVToDirection(u, v)
+	return direction, m.pdf(x, y, v)
+}
+
+// PDF returns the probability density of Sample choosing a direction.
+func (m Map) PDF(direction vector.Vector) float64 {
+	u, v := DirectionToUV(direction)
+	x, y := m.pixel(u, v)
+	return m.pdf(x, y, v)
+}
+
+func (m Map) pdf(x, y int, v float64) float64 {
+	sinTheta := math.Sin(v * math.Pi)
+	if sinTheta == 0 {
+		return 0
+	}
+	return m.weights[y][x] / (2 * math.Pi * math.Pi * sinTheta)
+}
+
+func (m Map) pixel(u, v float64) (int, int) {
+	x := int(u * float64(m.Image.Width))
+	y := int(v * float64(m.Image.Height))
+	return clampIndex(x, m.Image.Width), clampIndex(y, m.Image.Height)
+}
+
+func clampIndex(i, size int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= size {
+		return size - 1
+	}
+	return i
+}
+
+// sampleCDF returns the index chosen by u from a cumulative distribution and
+// the offset of u within that index's interval.
+func sampleCDF(cdf []float64, u float64) (int, float64) {
+	i := sort.Search(len(cdf), func(j int) bool { return cdf[j] > u })
+	if i >= len(cdf) {
+		i = len(cdf) - 1
+	}
+	low := 0.0
+	if i > 0 {
+		low = cdf[i-1]
+	}
+	if cdf[i] == low {
+		return i, 0.5
+	}
+	return i, (u - low) / (cdf[i] - low)
+}
+
+// buildCDF returns the normalized cumulative distribution of values and their sum.
+func buildCDF(values []float64) ([]float64, float64) {
+	cdf := make([]float64, len(values))
+	total := 0.0
+	for i, value := range values {
+		total += value
+		cdf[i] = total
+	}
+	for i := range cdf {
+		if total > 0 {
+			cdf[i] /= total
+		} else {
+			cdf[i] = float64(i+1) / float64(len(cdf))
+		}
+	}
+	return cdf, total
+}
+
+// NewMap returns an environment map for an equirectangular image.
+func NewMap(image canvas.Canvas, intensity float64) Map {
+	m := Map{Image: image, Intensity: intensity}
+	rows := make([]float64, image.Height)
+	luminance := make([][]float64, image.Height)
+	for y := 0; y < image.Height; y++ {
+		// Rows near the poles cover less solid angle.
+		sinTheta := math.Sin((float64(y) + 0.5) / float64(image.Height) * math.Pi)
+		luminance[y] = make([]float64, image.Width)
+		for x := 0; x < image.Width; x++ {
+			luminance[y][x] = math.Max(0, image.Pixel(x, y).Luminance()) * sinTheta
+		}
+		var cdf []float64
+		cdf, rows[y] = buildCDF(luminance[y])
+		m.columnCDFs = append(m.columnCDFs, cdf)
+	}
+	var total float64
+	m.rowCDF, total = buildCDF(rows)
+	pixels := float64(image.Width * image.Height)
+	m.weights = make([][]float64, image.Height)
+	for y := 0; y < image.Height; y++ {
+		m.weights[y] = make([]float64, image.Width)
+		for x := 0; x < image.Width; x++ {
+			if total > 0 {
+				m.weights[y][x] = luminance[y][x] * pixels / total
+			} else {
+				m.weights[y][x] = 1
+			}
+		}
+	}
+	return m
+}
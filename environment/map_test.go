@@ -0,0 +1,72 @@
+package environment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/canvas"
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func bright(x, y int) canvas.Canvas {
+	image := canvas.New(8, 4)
+	for i := 0; i < image.Width; i++ {
+		for j := 0; j < image.Height; j++ {
+			image.WritePixel(i, j, colour.New(0.1, 0.1, 0.1))
+		}
+	}
+	image.WritePixel(x, y, colour.New(100, 100, 100))
+	return image
+}
+
+func TestMapColourAt(t *testing.T) {
+	m := NewMap(bright(6, 1), 2)
+	var tests = []struct {
+		direction vector.Vector
+		expected  colour.Colour
+	}{
+		{direction: UVToDirection(0.8, 0.3), expected: colour.New(200, 200, 200)},
+		{direction: vector.NewVector(0, 0, -1), expected: colour.New(0.2, 0.2, 0.2)},
+	}
+	for _, test := range tests {
+		result := m.ColourAt(test.direction)
+		if result.Equal(test.expected) != true {
+			t.Errorf("Map colour at %+v was %+v, expected %+v.", test.direction, result, test.expected)
+		}
+	}
+}
+
+func TestMapSampleFavoursBrightPixels(t *testing.T) {
+	m := NewMap(bright(6, 1), 1)
+	sampling.Seed(1)
+	hits := 0
+	const n = 1000
+	for i := 0; i < n; i++ {
+		d, pdf := m.Sample(sampling.Float64(), sampling.Float64())
+		if math.Abs(pdf-m.PDF(d)) > 1e-6*pdf {
+			t.Fatalf("Map sample pdf was %v, but PDF returned %v.", pdf, m.PDF(d))
+		}
+		if x, y := m.pixel(DirectionToUV(d)); x == 6 && y == 1 {
+			hits++
+		}
+	}
+	if hits < n*9/10 {
+		t.Errorf("Map sampled the bright pixel %d times out of %d.", hits, n)
+	}
+}
+
+func TestMapPDFIntegratesToOne(t *testing.T) {
+	m := NewMap(bright(2, 2), 1)
+	const n = 50000
+	sampling.Seed(1)
+	total := 0.0
+	for i := 0; i < n; i++ {
+		d := sampling.UniformSphere(sampling.Float64(), sampling.Float64())
+		total += m.PDF(d) / sampling.UniformSpherePDF
+	}
+	if math.Abs(total/n-1) > 0.05 {
+		t.Errorf("Integral of map pdf was %v, expected 1.", total/n)
+	}
+}
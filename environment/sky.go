@@ -0,0 +1,148 @@
+package environment
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/light"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Sky is a procedural daylight sky using the Preetham, Shirley and Smits
+// analytic model.
+type Sky struct {
+	// SunDirection is the normalized direction towards the sun.
+	SunDirection vector.Vector
+	Turbidity    float64
+	// Intensity scales the sky's luminance, which the model gives in kcd/m².
+	Intensity float64
+	// GroundAlbedo scales the horizon colour to give the colour below it.
+	GroundAlbedo float64
+	// zenith holds the zenith luminance and chromaticity, perez the
+	// distribution coefficients for Y, x and y.
+	zenith [3]float64
+	perez  [3][5]float64
+}
+
+// ColourAt returns the colour of the sky in a direction.
+func (s Sky) ColourAt(direction vector.Vector) colour.Colour {
+	scale := s.Intensity
+	if direction.Y < 0 {
+		direction.Y = 0
+		scale *= s.GroundAlbedo
+		if direction.X == 0 && direction.Z == 0 {
+			// Straight down has no horizon below it, so use a fixed one.
+			direction.Z = -1
+		}
+	}
+	direction = direction.Normalize()
+	cosTheta := math.Max(direction.Y, 0.01)
+	cosGamma := math.Max(-1, math.Min(1, vector.DotProduct(direction, s.SunDirection)))
+	gamma := math.Acos(cosGamma)
+	thetaS := math.Acos(math.Max(-1, math.Min(1, s.SunDirection.Y)))
+	var values [3]float64
+	for i := range values {
+		values[i] = s.zenith[i] * perez(s.perez[i], cosTheta, gamma, cosGamma) /
+			perez(s.perez[i], 1, thetaS, math.Cos(thetaS))
+	}
+	luminance, x, y := values[0]*scale, values[1], values[2]
+	if y <= 0 {
+		return colour.New(0, 0, 0)
+	}
+	return colour.FromXYZ(x/y*luminance, luminance, (1-x-y)/y*luminance)
+}
+
+// Sample returns a direction uniformly distributed over the sphere.
+func (s Sky) Sample(u1, u2 float64) (vector.Vector, float64) {
+	return sampling.UniformSphere(u1, u2), sampling.UniformSpherePDF
+}
+
+// PDF returns the probability density of sampling a direction.
+func (s Sky) PDF(direction vector.Vector) float64 {
+	return sampling.UniformSpherePDF
+}
+
+// Sun returns a directional light for the sky's sun. Its colour is the sun's
+// light after passing through the atmosphere, scaled by intensity.
+func (s Sky) Sun(intensity float64) *light.Directional {
+	c := colour.New(0, 0, 0)
+	if s.SunDirection.Y > 0 {
+		c = sunTransmittance(s.SunDirection.Y, s.Turbidity).ScalarMult(intensity)
+	}
+	return light.NewDirectional(c, s.SunDirection.Negate())
+}
+
+// perez is the Perez sky luminance distribution function.
+func perez(c [5]float64, cosTheta, gamma, cosGamma float64) float64 {
+	return (1 + c[0]*math.Exp(c[1]/cosTheta)) *
+		(1 + c[2]*math.Exp(c[3]*gamma) + c[4]*cosGamma*cosGamma)
+}
+
+// sunTransmittance returns the fraction of sunlight at the red, green and blue
+// wavelengths which reaches the ground through Rayleigh and aerosol scattering.
+func sunTransmittance(cosThetaS, turbidity float64) colour.Colour {
+	thetaS := math.Acos(cosThetaS) * 180 / math.Pi
+	airMass := 1 / (cosThetaS + 0.15*math.Pow(93.885-thetaS, -1.253))
+	beta := 0.04608*turbidity - 0.04586
+	var t [3]float64
+	for i, lambda := range []float64{0.680, 0.550, 0.440} {
+		rayleigh := 0.008735 * math.Pow(lambda, -4.08)
+		aerosol := beta * math.Pow(lambda, -1.3)
+		t[i] = math.Exp(-airMass * (rayleigh + aerosol))
+	}
+	return colour.New(t[0], t[1], t[2])
+}
+
+// NewSky returns a sky for a sun at elevation radians above the horizon and
+// azimuth radians clockwise from -z towards +x, in an atmosphere of the given
+// turbidity. Clear skies have a turbidity of about 2, hazy skies about 6.
+func NewSky(elevation, azimuth, turbidity float64) Sky {
+	s := Sky{
+		SunDirection: vector.NewVector(
+			math.Cos(elevation)*math.Sin(azimuth),
+			math.Sin(elevation),
+			-math.Cos(elevation)*math.Cos(azimuth),
+		),
+		Turbidity: turbidity, Intensity: 0.1, GroundAlbedo: 0.3,
+	}
+	t := turbidity
+	thetaS := math.Pi/2 - math.Max(0, elevation)
+	chi := (4.0/9 - t/120) * (math.Pi - 2*thetaS)
+	s.zenith[0] = (4.0453*t-4.9710)*math.Tan(chi) - 0.2155*t + 2.4192
+	s.zenith[1] = zenithChromaticity(t, thetaS, [3][4]float64{
+		{0.00166, -0.00375, 0.00209, 0},
+		{-0.02903, 0.06377, -0.03202, 0.00394},
+		{0.11693, -0.21196, 0.06052, 0.25886},
+	})
+	s.zenith[2] = zenithChromaticity(t, thetaS, [3][4]float64{
+		{0.00275, -0.00610, 0.00317, 0},
+		{-0.04214, 0.08970, -0.04153, 0.00516},
+		{0.15346, -0.26756, 0.06670, 0.26688},
+	})
+	s.perez[0] = [5]float64{
+		0.1787*t - 1.4630, -0.3554*t + 0.4275, -0.0227*t + 5.3251,
+		0.1206*t - 2.5771, -0.0670*t + 0.3703,
+	}
+	s.perez[1] = [5]float64{
+		-0.0193*t - 0.2592, -0.0665*t + 0.0008, -0.0004*t + 0.2125,
+		-0.0641*t - 0.8989, -0.0033*t + 0.0452,
+	}
+	s.perez[2] = [5]float64{
+		-0.0167*t - 0.2608, -0.0950*t + 0.0092, -0.0079*t + 0.2102,
+		-0.0441*t - 1.6537, -0.0109*t + 0.0529,
+	}
+	return s
+}
+
+func zenithChromaticity(t, thetaS float64, m [3][4]float64) float64 {
+	theta := []float64{thetaS * thetaS * thetaS, thetaS * thetaS, thetaS, 1}
+	turbidity := []float64{t * t, t, 1}
+	value := 0.0
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 4; j++ {
+			value += turbidity[i] * m[i][j] * theta[j]
+		}
+	}
+	return value
+}
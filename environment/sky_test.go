@@ -0,0 +1,103 @@
+package environment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestNewSkySunDirection(t *testing.T) {
+	var tests = []struct {
+		elevation, azimuth float64
+		expected           vector.Vector
+	}{
+		{elevation: math.Pi / 2, azimuth: 0, expected: vector.NewVector(0, 1, 0)},
+		{elevation: 0, azimuth: 0, expected: vector.NewVector(0, 0, -1)},
+		{elevation: 0, azimuth: math.Pi / 2, expected: vector.NewVector(1, 0, 0)},
+	}
+	for _, test := range tests {
+		s := NewSky(test.elevation, test.azimuth, 3)
+		if vector.Equal(s.SunDirection, test.expected) != true {
+			t.Errorf(
+				"Sky with elevation %v and azimuth %v had sun direction %+v, expected %+v.",
+				test.elevation, test.azimuth, s.SunDirection, test.expected,
+			)
+		}
+	}
+}
+
+func TestSkyZenithLuminance(t *testing.T) {
+	s := NewSky(math.Pi/3, 0, 3)
+	result := s.ColourAt(vector.NewVector(0, 1, 0)).Luminance()
+	expected := s.zenith[0] * s.Intensity
+	if math.Abs(result-expected) > 0.01*expected {
+		t.Errorf("Sky zenith luminance was %v, expected %v.", result, expected)
+	}
+}
+
+func TestSkyIsBlue(t *testing.T) {
+	s := NewSky(math.Pi/3, 0, 2.5)
+	c := s.ColourAt(vector.NewVector(0, 1, 0))
+	if c.Blue <= c.Red {
+		t.Errorf("Clear midday zenith colour %+v is not blue.", c)
+	}
+}
+
+func TestSkyBrighterTowardsSun(t *testing.T) {
+	s := NewSky(math.Pi/6, 0, 3)
+	towards := s.ColourAt(vector.NewVector(0, 0.6, -0.8)).Luminance()
+	away := s.ColourAt(vector.NewVector(0, 0.6, 0.8)).Luminance()
+	if towards <= away {
+		t.Errorf("Sky luminance towards sun %v was not greater than away %v.", towards, away)
+	}
+}
+
+func TestSkyGround(t *testing.T) {
+	s := NewSky(math.Pi/4, 0.5, 3)
+	horizon := s.ColourAt(vector.NewVector(0, 0, -1))
+	var tests = []struct {
+		direction vector.Vector
+		expected  float64
+	}{
+		{direction: vector.NewVector(0, -1, 0), expected: horizon.Luminance() * s.GroundAlbedo},
+		{direction: vector.NewVector(0, -0.6, -0.8), expected: horizon.Luminance() * s.GroundAlbedo},
+	}
+	for _, test := range tests {
+		result := s.ColourAt(test.direction).Luminance()
+		if math.IsNaN(result) || math.Abs(result-test.expected) > 1e-9 {
+			t.Errorf("Sky luminance towards %v was %v, expected %v.", test.direction, result, test.expected)
+		}
+	}
+}
+
+func TestSkyDeterministic(t *testing.T) {
+	a := NewSky(0.4, 1.2, 4)
+	b := NewSky(0.4, 1.2, 4)
+	d := vector.NewVector(0.3, 0.5, -0.2)
+	if a.ColourAt(d) != b.ColourAt(d) {
+		t.Error("Identical skies produced different colours.")
+	}
+}
+
+func TestSun(t *testing.T) {
+	high := NewSky(math.Pi/2*0.9, 0, 3).Sun(1)
+	low := NewSky(0.05, 0, 3).Sun(1)
+	if high.Intensity().Luminance() <= low.Intensity().Luminance() {
+		t.Errorf(
+			"High sun %+v was not brighter than low sun %+v.", high.Intensity(), low.Intensity(),
+		)
+	}
+	c := low.Intensity()
+	if c.Red <= c.Blue {
+		t.Errorf("Low sun %+v is not red.", c)
+	}
+	set := NewSky(-0.1, 0, 3).Sun(1)
+	if set.Intensity().Luminance() != 0 {
+		t.Errorf("Sun below the horizon had intensity %+v.", set.Intensity())
+	}
+	s := NewSky(math.Pi/4, 0, 3)
+	if vector.Equal(s.Sun(1).Direction(), s.SunDirection.Negate()) != true {
+		t.Errorf("Sun light direction was %+v, expected %+v.", s.Sun(1).Direction(), s.SunDirection.Negate())
+	}
+}
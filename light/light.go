@@ -1,6 +1,8 @@
 package light
 
 import (
+	"math"
+
 	"github.com/lukeshiner/raytrace/colour"
 	"github.com/lukeshiner/raytrace/vector"
 )
@@ -11,6 +13,7 @@ type Light interface {
 	SetIntensity(i colour.Colour)
 	Position() vector.Vector
 	SetPosition(p vector.Vector)
+	DirectionFrom(p vector.Vector) (vector.Vector, float64)
 }
 
 // Point holds point light data.
@@ -39,7 +42,61 @@ func (p *Point) SetPosition(pos vector.Vector) {
 	p.position = pos
 }
 
+// DirectionFrom returns the normalized direction from p to the light and the
+// distance between them.
+func (p Point) DirectionFrom(pos vector.Vector) (vector.Vector, float64) {
+	v := vector.Subtract(p.position, pos)
+	return v.Normalize(), v.Magnitude()
+}
+
 // NewPoint creates a new point light
 func NewPoint(intensity colour.Colour, position vector.Vector) *Point {
 	return &Point{intensity: intensity, position: position}
 }
+
+// Directional holds data for a light infinitely far away, such as the sun.
+type Directional struct {
+	intensity colour.Colour
+	direction vector.Vector
+}
+
+// Intensity returns the intensity of the light.
+func (d Directional) Intensity() colour.Colour {
+	return d.intensity
+}
+
+// SetIntensity sets the intensity of the light
+func (d *Directional) SetIntensity(i colour.Colour) {
+	d.intensity = i
+}
+
+// Position returns the position of the light, which is at infinity in the
+// opposite direction to the one the light travels.
+func (d Directional) Position() vector.Vector {
+	return d.direction.Negate()
+}
+
+// SetPosition sets the position of the light to infinity in the direction of pos
+// from the origin.
+func (d *Directional) SetPosition(pos vector.Vector) {
+	pos.W = 0
+	pos = pos.Normalize()
+	d.direction = pos.Negate()
+}
+
+// Direction returns the normalized direction in which the light travels.
+func (d Directional) Direction() vector.Vector {
+	return d.direction
+}
+
+// DirectionFrom returns the normalized direction from p to the light, which is
+// an infinite distance away.
+func (d Directional) DirectionFrom(p vector.Vector) (vector.Vector, float64) {
+	return d.direction.Negate(), math.Inf(1)
+}
+
+// NewDirectional creates a new directional light shining in direction.
+func NewDirectional(intensity colour.Colour, direction vector.Vector) *Directional {
+	direction.W = 0
+	return &Directional{intensity: intensity, direction: direction.Normalize()}
+}
@@ -1,6 +1,7 @@
 package light
 
 import (
+	"math"
 	"testing"
 
 	"github.com/lukeshiner/raytrace/colour"
@@ -46,3 +47,31 @@ func TestPointPosition(t *testing.T) {
 		t.Error("Could not set point light position.")
 	}
 }
+
+func TestPointDirectionFrom(t *testing.T) {
+	l := NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 10, 0))
+	direction, distance := l.DirectionFrom(vector.NewPoint(0, 2, 0))
+	if vector.Equal(direction, vector.NewVector(0, 1, 0)) != true || distance != 8 {
+		t.Errorf("Point light DirectionFrom returned %+v, %v.", direction, distance)
+	}
+}
+
+func TestDirectional(t *testing.T) {
+	intensity := colour.New(1, 0.9, 0.8)
+	l := NewDirectional(intensity, vector.NewVector(0, -2, 0))
+	if l.Intensity().Equal(intensity) != true {
+		t.Errorf("Directional light intensity was %+v, expected %+v.", l.Intensity(), intensity)
+	}
+	if vector.Equal(l.Direction(), vector.NewVector(0, -1, 0)) != true {
+		t.Errorf("Directional light direction was %+v, expected (0, -1, 0).", l.Direction())
+	}
+	direction, distance := l.DirectionFrom(vector.NewPoint(5, 0, 3))
+	if vector.Equal(direction, vector.NewVector(0, 1, 0)) != true || !math.IsInf(distance, 1) {
+		t.Errorf("Directional light DirectionFrom returned %+v, %v.", direction, distance)
+	}
+	l.SetPosition(vector.NewPoint(0, 0, -4))
+	if vector.Equal(l.Direction(), vector.NewVector(0, 0, 1)) != true ||
+		vector.Equal(l.Position(), vector.NewVector(0, 0, -1)) != true {
+		t.Errorf("Setting directional light position produced %+v.", l)
+	}
+}
@@ -1,17 +1,126 @@
 package material
 
-import "github.com/lukeshiner/raytrace/colour"
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/bsdf"
+	"github.com/lukeshiner/raytrace/bump"
+	"github.com/lukeshiner/raytrace/colour"
+)
+
+// Model is the type for the ways a material reflects light.
+type Model int
+
+const (
+	// Phong uses Ambient, Diffuse, Specular and Shininess.
+	Phong Model = iota
+	// MetallicRoughness is a physically based GGX microfacet model using
+	// Metallic and Roughness, with Colour as the base colour.
+	MetallicRoughness
+)
+
+// Coat holds data for a clear coat, such as lacquer, over a material.
+type Coat struct {
+	IOR, Roughness float64
+	// Tint is the colour of light passing down through the coat and back at
+	// normal incidence. When it is zero the coat is clear.
+	Tint colour.Colour
+}
+
+// NewCoat returns a colourless coat.
+func NewCoat(ior, roughness float64) Coat {
+	return Coat{IOR: ior, Roughness: roughness, Tint: colour.New(1, 1, 1)}
+}
 
 // Material holds data for materials.
 type Material struct {
 	Colour                                colour.Colour
 	Ambient, Diffuse, Specular, Shininess float64
+	// DiffuseRoughness is the standard deviation in radians of the angles of
+	// the facets of a rough Phong surface, giving Oren-Nayar diffuse light.
+	// When it is zero the diffuse light is Lambertian.
+	DiffuseRoughness float64
+	// Emission is the light given off by the surface.
+	Emission colour.Colour
+	Model    Model
+	// Metallic is from 0 for a dielectric to 1 for a metal, Roughness from 0
+	// for a mirror to 1 for a matte surface.
+	Metallic, Roughness float64
+	// When BitangentRoughness is more than zero highlights are anisotropic,
+	// with Roughness along the surface's tangent and BitangentRoughness across
+	// it, as on brushed metal.
+	BitangentRoughness float64
+	// BSDF replaces the model when it is set.
+	BSDF bsdf.BSDF
+	// Coat is over the surface when its IOR is more than zero.
+	Coat Coat
+	// Light travelling through the inside of the object is tinted to
+	// Absorption over each unit of distance at a Density of one, following
+	// the Beer-Lambert law. When Density is zero nothing is absorbed.
+	Absorption colour.Colour
+	Density    float64
+	// Bump perturbs the shading normal of surfaces with texture co-ordinates
+	// when it is set.
+	Bump bump.Map
 }
 
 // New returns a new material
 func New() Material {
 	return Material{
 		Colour: colour.New(1, 1, 1), Ambient: 0.1, Diffuse: 0.9, Specular: 0.9,
-		Shininess: 200.0,
+		Shininess: 200.0, Emission: colour.New(0, 0, 0), Model: Phong, Roughness: 0.5,
+	}
+}
+
+// NewMetallicRoughness returns a new physically based material.
+func NewMetallicRoughness(base colour.Colour, metallic, roughness float64) Material {
+	m := New()
+	m.Model = MetallicRoughness
+	m.Colour, m.Metallic, m.Roughness = base, metallic, roughness
+	return m
+}
+
+// Surface returns the BSDF of the material, made from its model unless it has
+// its own, under its coat.
+func (m Material) Surface() bsdf.BSDF {
+	base := m.base()
+	if m.Coat.IOR > 0 {
+		return bsdf.ClearCoat{
+			Base: base, IOR: m.Coat.IOR, Roughness: m.Coat.Roughness, Tint: m.Coat.Tint,
+		}
+	}
+	return base
+}
+
+func (m Material) base() bsdf.BSDF {
+	if m.BSDF != nil {
+		return m.BSDF
+	}
+	if m.Model == MetallicRoughness {
+		return bsdf.MetallicRoughness{
+			Colour: m.Colour, Metallic: m.Metallic, Roughness: m.Roughness,
+			BitangentRoughness: m.BitangentRoughness,
+		}
+	}
+	return bsdf.Phong{
+		Colour: m.Colour, Diffuse: m.Diffuse, Specular: m.Specular, Shininess: m.Shininess,
+		Roughness: m.DiffuseRoughness,
+	}
+}
+
+// Emissive returns true if the material gives off light.
+func (m Material) Emissive() bool {
+	return m.Emission.Red > 0 || m.Emission.Green > 0 || m.Emission.Blue > 0
+}
+
+// Transmittance returns the fraction of light which is not absorbed over a
+// distance through the inside of the object.
+func (m Material) Transmittance(distance float64) colour.Colour {
+	if m.Density <= 0 {
+		return colour.New(1, 1, 1)
 	}
+	d := m.Density * distance
+	return colour.New(
+		math.Pow(m.Absorption.Red, d), math.Pow(m.Absorption.Green, d), math.Pow(m.Absorption.Blue, d),
+	)
 }
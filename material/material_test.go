@@ -3,6 +3,7 @@ package material
 import (
 	"testing"
 
+	"github.com/lukeshiner/raytrace/bsdf"
 	"github.com/lukeshiner/raytrace/colour"
 )
 
@@ -28,3 +29,103 @@ func TestDefaultMaterial(t *testing.T) {
 		}
 	}
 }
+
+func TestEmissive(t *testing.T) {
+	var tests = []struct {
+		emission colour.Colour
+		expected bool
+	}{
+		{emission: colour.New(0, 0, 0), expected: false},
+		{emission: colour.New(0, 0, 2), expected: true},
+	}
+	for _, test := range tests {
+		m := New()
+		m.Emission = test.emission
+		if m.Emissive() != test.expected {
+			t.Errorf("Material emitting %+v was emissive %v, expected %v.", test.emission, m.Emissive(), test.expected)
+		}
+	}
+}
+
+func TestNewMetallicRoughness(t *testing.T) {
+	m := NewMetallicRoughness(colour.New(1, 0.8, 0.3), 1, 0.25)
+	if m.Model != MetallicRoughness || m.Colour != colour.New(1, 0.8, 0.3) ||
+		m.Metallic != 1 || m.Roughness != 0.25 || m.Ambient != 0.1 {
+		t.Errorf("Metallic roughness material was %+v.", m)
+	}
+}
+
+func TestSurface(t *testing.T) {
+	phong := New()
+	pbr := NewMetallicRoughness(colour.New(1, 0.8, 0.3), 1, 0.25)
+	brushed := NewMetallicRoughness(colour.New(0.9, 0.9, 0.9), 1, 0.6)
+	brushed.BitangentRoughness = 0.1
+	rough := New()
+	rough.DiffuseRoughness = 0.5
+	coated := New()
+	coated.BSDF = bsdf.Lambert{Albedo: colour.New(1, 0, 0)}
+	coated.Coat = NewCoat(1.5, 0.1)
+	custom := New()
+	custom.BSDF = bsdf.NewDielectric(1.5)
+	var tests = []struct {
+		material Material
+		expected bsdf.BSDF
+	}{
+		{
+			material: phong,
+			expected: bsdf.Phong{Colour: colour.New(1, 1, 1), Diffuse: 0.9, Specular: 0.9, Shininess: 200},
+		},
+		{
+			material: pbr,
+			expected: bsdf.MetallicRoughness{Colour: colour.New(1, 0.8, 0.3), Metallic: 1, Roughness: 0.25},
+		},
+		{
+			material: brushed,
+			expected: bsdf.MetallicRoughness{
+				Colour: colour.New(0.9, 0.9, 0.9), Metallic: 1, Roughness: 0.6, BitangentRoughness: 0.1,
+			},
+		},
+		{
+			material: rough,
+			expected: bsdf.Phong{
+				Colour: colour.New(1, 1, 1), Diffuse: 0.9, Specular: 0.9, Shininess: 200, Roughness: 0.5,
+			},
+		},
+		{
+			material: coated,
+			expected: bsdf.NewClearCoat(bsdf.Lambert{Albedo: colour.New(1, 0, 0)}, 1.5, 0.1),
+		},
+		{material: custom, expected: bsdf.NewDielectric(1.5)},
+	}
+	for _, test := range tests {
+		if result := test.material.Surface(); result != test.expected {
+			t.Errorf("Surface of %+v was %+v, expected %+v.", test.material, result, test.expected)
+		}
+	}
+}
+
+func TestTransmittance(t *testing.T) {
+	var tests = []struct {
+		absorption        colour.Colour
+		density, distance float64
+		expected          colour.Colour
+	}{
+		// Nothing is absorbed without a density.
+		{absorption: colour.New(0, 0, 0), density: 0, distance: 5, expected: colour.New(1, 1, 1)},
+		{absorption: colour.New(0.5, 1, 0.8), density: 1, distance: 1, expected: colour.New(0.5, 1, 0.8)},
+		{absorption: colour.New(0.5, 1, 0.8), density: 1, distance: 2, expected: colour.New(0.25, 1, 0.64)},
+		{absorption: colour.New(0.5, 1, 0.8), density: 2, distance: 0.5, expected: colour.New(0.5, 1, 0.8)},
+		{absorption: colour.New(0.5, 1, 0.8), density: 1, distance: 0, expected: colour.New(1, 1, 1)},
+	}
+	for _, test := range tests {
+		m := New()
+		m.Absorption, m.Density = test.absorption, test.density
+		result := m.Transmittance(test.distance)
+		if result.Equal(test.expected) != true {
+			t.Errorf(
+				"Transmittance of %v at density %v over %v was %v, expected %v.",
+				test.absorption, test.density, test.distance, result, test.expected,
+			)
+		}
+	}
+}
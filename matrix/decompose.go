@@ -0,0 +1,201 @@
+package matrix
+
+import (
+	"math"
+)
+
+// Decomposition holds an affine transform matrix split into a translation, a
+// rotation and a scale, which can be interpolated separately.
+type Decomposition struct {
+	Translation [3]float64
+	// Rotation is a unit quaternion (w, x, y, z).
+	Rotation [4]float64
+	// Scale is a 3x3 matrix which may include shearing.
+	Scale Matrix
+}
+
+// Decompose returns the decomposition of a 4x4 affine transform matrix m, such
+// that m is the translation multiplied by the rotation multiplied by the scale.
+func Decompose(m Matrix) Decomposition {
+	d := Decomposition{Translation: [3]float64{m.Get(0, 3), m.Get(1, 3), m.Get(2, 3)}}
+	linear := m.Submatrix(3, 3)
+	rotation := polarRotation(linear)
+	if rotation.determinant() < 0 {
+		// Take any reflection into the scale so the rotation is proper.
+		rotation = scalarMultiply(rotation, -1)
+	}
+	d.Rotation = quaternionFromMatrix(rotation)
+	d.Scale = Multiply(rotation.Transpose(), linear)
+	return d
+}
+
+// Matrix returns the transform matrix for the decomposition.
+func (d Decomposition) Matrix() Matrix {
+	linear := Multiply(quaternionToMatrix(d.Rotation), d.Scale)
+	m := IdentityMatrix(4)
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			m.Cells[x][y] = linear.Get(x, y)
+		}
+		m.Cells[x][3] = d.Translation[x]
+	}
+	return m
+}
+
+// Interpolate returns the transform a fraction t of the way from transform a to
+// transform b. Translations and scales are interpolated linearly and rotations
+// spherically.
+func Interpolate(a, b Matrix, t float64) Matrix {
+	return NewMotion(a, b).At(t)
+}
+
+// Motion is a movement from transform Start to transform End, decomposed once
+// so it can be interpolated quickly.
+type Motion struct {
+	Start, End Matrix
+	start, end Decomposition
+}
+
+// NewMotion returns the motion from transform start to transform end.
+func NewMotion(start, end Matrix) Motion {
+	return Motion{Start: start, End: end, start: Decompose(start), end: Decompose(end)}
+}
+
+// At returns the transform a fraction t of the way through the motion, like
+// Interpolate.
+func (m Motion) At(t float64) Matrix {
+	da, db := m.start, m.end
+	var d Decomposition
+	for i := 0; i < 3; i++ {
+		d.Translation[i] = lerp(da.Translation[i], db.Translation[i], t)
+	}
+	d.Rotation = slerp(da.Rotation, db.Rotation, t)
+	var cells [][]float64
+	for x := 0; x < 3; x++ {
+		row := make([]float64, 3)
+		for y := 0; y < 3; y++ {
+			row[y] = lerp(da.Scale.Get(x, y), db.Scale.Get(x, y), t)
+		}
+		cells = append(cells, row)
+	}
+	d.Scale = New(cells...)
+	return d.Matrix()
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// polarRotation returns the rotation part of the polar decomposition of m, by
+// repeatedly averaging it with its inverse transpose.
+func polarRotation(m Matrix) Matrix {
+	r := m
+	for i := 0; i < 100; i++ {
+		inverse, err := r.Invert()
+		if err != nil {
+			return IdentityMatrix(3)
+		}
+		next := scalarMultiply(add(r, inverse.Transpose()), 0.5)
+		converged := Equal(next, r)
+		r = next
+		if converged {
+			break
+		}
+	}
+	return r
+}
+
+func add(a, b Matrix) Matrix {
+	var cells [][]float64
+	for x := 0; x < a.Height; x++ {
+		row := make([]float64, a.Width)
+		for y := 0; y < a.Width; y++ {
+			row[y] = a.Get(x, y) + b.Get(x, y)
+		}
+		cells = append(cells, row)
+	}
+	return New(cells...)
+}
+
+func scalarMultiply(m Matrix, scalar float64) Matrix {
+	var cells [][]float64
+	for x := 0; x < m.Height; x++ {
+		row := make([]float64, m.Width)
+		for y := 0; y < m.Width; y++ {
+			row[y] = m.Get(x, y) * scalar
+		}
+		cells = append(cells, row)
+	}
+	return New(cells...)
+}
+
+func quaternionFromMatrix(m Matrix) [4]float64 {
+	var q [4]float64
+	trace := m.Get(0, 0) + m.Get(1, 1) + m.Get(2, 2)
+	switch {
+	case trace > 0:
+		s := 2 * math.Sqrt(trace+1)
+		q = [4]float64{
+			s / 4, (m.Get(2, 1) - m.Get(1, 2)) / s,
+			(m.Get(0, 2) - m.Get(2, 0)) / s, (m.Get(1, 0) - m.Get(0, 1)) / s,
+		}
+	case m.Get(0, 0) > m.Get(1, 1) && m.Get(0, 0) > m.Get(2, 2):
+		s := 2 * math.Sqrt(1+m.Get(0, 0)-m.Get(1, 1)-m.Get(2, 2))
+		q = [4]float64{
+			(m.Get(2, 1) - m.Get(1, 2)) / s, s / 4,
+			(m.Get(0, 1) + m.Get(1, 0)) / s, (m.Get(0, 2) + m.Get(2, 0)) / s,
+		}
+	case m.Get(1, 1) > m.Get(2, 2):
+		s := 2 * math.Sqrt(1+m.Get(1, 1)-m.Get(0, 0)-m.Get(2, 2))
+		q = [4]float64{
+			(m.Get(0, 2) - m.Get(2, 0)) / s, (m.Get(0, 1) + m.Get(1, 0)) / s,
+			s / 4, (m.Get(1, 2) + m.Get(2, 1)) / s,
+		}
+	default:
+		s := 2 * math.Sqrt(1+m.Get(2, 2)-m.Get(0, 0)-m.Get(1, 1))
+		q = [4]float64{
+			(m.Get(1, 0) - m.Get(0, 1)) / s, (m.Get(0, 2) + m.Get(2, 0)) / s,
+			(m.Get(1, 2) + m.Get(2, 1)) / s, s / 4,
+		}
+	}
+	return normalizeQuaternion(q)
+}
+
+func quaternionToMatrix(q [4]float64) Matrix {
+	w, x, y, z := q[0], q[1], q[2], q[3]
+	return New(
+		[]float64{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		[]float64{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		[]float64{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	)
+}
+
+func normalizeQuaternion(q [4]float64) [4]float64 {
+	length := math.Sqrt(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3])
+	return [4]float64{q[0] / length, q[1] / length, q[2] / length, q[3] / length}
+}
+
+// slerp returns the spherical interpolation a fraction t of the way between
+// unit quaternions a and b, along the shorter arc.
+func slerp(a, b [4]float64, t float64) [4]float64 {
+	cosTheta := a[0]*b[0] + a[1]*b[1] + a[2]*b[2] + a[3]*b[3]
+	if cosTheta < 0 {
+		cosTheta = -cosTheta
+		b = [4]float64{-b[0], -b[1], -b[2], -b[3]}
+	}
+	var q [4]float64
+	if cosTheta > 0.9995 {
+		// Nearly parallel, so interpolate linearly.
+		for i := range q {
+			q[i] = lerp(a[i], b[i], t)
+		}
+		return normalizeQuaternion(q)
+	}
+	theta := math.Acos(cosTheta)
+	wa := math.Sin((1-t)*theta) / math.Sin(theta)
+	wb := math.Sin(t*theta) / math.Sin(theta)
+	for i := range q {
+		q[i] = wa*a[i] + wb*b[i]
+	}
+	return q
+}
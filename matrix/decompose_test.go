@@ -0,0 +1,120 @@
+package matrix
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecompose(t *testing.T) {
+	var tests = []Matrix{
+		IdentityMatrix(4),
+		TranslationMatrix(1, -2, 3),
+		RotationYMatrix(math.Pi / 3),
+		ScalingMatrix(2, 3, 4),
+		ScalingMatrix(-1, 1, 1),
+		Multiply(
+			Multiply(TranslationMatrix(5, 0, -1), RotationZMatrix(2)),
+			Multiply(RotationXMatrix(0.5), ScalingMatrix(1, 2, 0.5)),
+		),
+		Multiply(RotationYMatrix(1), ShearingMatrix(1, 0, 0, 0, 0.5, 0)),
+	}
+	for _, test := range tests {
+		d := Decompose(test)
+		result := d.Matrix()
+		if Equal(result, test) != true {
+			t.Errorf("Decomposition of %+v recomposed as %+v.", test, result)
+		}
+	}
+}
+
+func TestDecomposeParts(t *testing.T) {
+	m := Multiply(
+		TranslationMatrix(1, 2, 3),
+		Multiply(RotationZMatrix(math.Pi/2), ScalingMatrix(2, 2, 2)),
+	)
+	d := Decompose(m)
+	if d.Translation != [3]float64{1, 2, 3} {
+		t.Errorf("Decomposed translation was %v, expected [1 2 3].", d.Translation)
+	}
+	rotation := [4]float64{math.Sqrt(2) / 2, 0, 0, math.Sqrt(2) / 2}
+	for i := range rotation {
+		if math.Abs(d.Rotation[i]-rotation[i]) > 1e-5 {
+			t.Errorf("Decomposed rotation was %v, expected %v.", d.Rotation, rotation)
+			break
+		}
+	}
+	scale := New([]float64{2, 0, 0}, []float64{0, 2, 0}, []float64{0, 0, 2})
+	if Equal(d.Scale, scale) != true {
+		t.Errorf("Decomposed scale was %+v, expected %+v.", d.Scale, scale)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	var tests = []struct {
+		a, b     Matrix
+		t        float64
+		expected Matrix
+	}{
+		{
+			a:        TranslationMatrix(0, 0, 0),
+			b:        TranslationMatrix(2, 4, 0),
+			t:        0.5,
+			expected: TranslationMatrix(1, 2, 0),
+		},
+		{
+			// Rotations are interpolated by angle, not element by element.
+			a:        IdentityMatrix(4),
+			b:        RotationYMatrix(math.Pi / 2),
+			t:        0.5,
+			expected: RotationYMatrix(math.Pi / 4),
+		},
+		{
+			a:        ScalingMatrix(1, 1, 1),
+			b:        ScalingMatrix(3, 1, 1),
+			t:        0.25,
+			expected: ScalingMatrix(1.5, 1, 1),
+		},
+		{
+			a:        Multiply(TranslationMatrix(0, 1, 0), RotationZMatrix(0)),
+			b:        Multiply(TranslationMatrix(4, 1, 0), RotationZMatrix(math.Pi)),
+			t:        0.5,
+			expected: Multiply(TranslationMatrix(2, 1, 0), RotationZMatrix(math.Pi/2)),
+		},
+		{
+			a:        RotationXMatrix(0.3),
+			b:        TranslationMatrix(1, 1, 1),
+			t:        0,
+			expected: RotationXMatrix(0.3),
+		},
+		{
+			a:        RotationXMatrix(0.3),
+			b:        TranslationMatrix(1, 1, 1),
+			t:        1,
+			expected: TranslationMatrix(1, 1, 1),
+		},
+	}
+	for _, test := range tests {
+		result := Interpolate(test.a, test.b, test.t)
+		if Equal(result, test.expected) != true {
+			t.Errorf(
+				"Interpolate(%+v, %+v, %v) was %+v, expected %+v.",
+				test.a, test.b, test.t, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestMotion(t *testing.T) {
+	start := Multiply(TranslationMatrix(0, 1, 0), ScalingMatrix(2, 2, 2))
+	end := Multiply(TranslationMatrix(4, 1, 0), RotationZMatrix(math.Pi))
+	m := NewMotion(start, end)
+	if Equal(m.Start, start) != true || Equal(m.End, end) != true {
+		t.Errorf("Motion was from %+v to %+v, expected %+v to %+v.", m.Start, m.End, start, end)
+	}
+	for _, time := range []float64{0, 0.25, 0.5, 1} {
+		result, expected := m.At(time), Interpolate(start, end, time)
+		if Equal(result, expected) != true {
+			t.Errorf("Motion at %v was %+v, expected %+v.", time, result, expected)
+		}
+	}
+}
@@ -8,6 +8,9 @@ import (
 // Ray is the struct for raytracer rays.
 type Ray struct {
 	Origin, Direction vector.Vector
+	// Time is when the ray is cast, as a fraction of the way from the start to
+	// the end of any motion in the world.
+	Time float64
 }
 
 // Position returns the position of the ray at time t.
@@ -19,10 +22,15 @@ func (r *Ray) Position(t float64) vector.Vector {
 func (r *Ray) Transform(m matrix.Matrix) Ray {
 	origin := vector.MultiplyMatrixByVector(m, r.Origin)
 	direction := vector.MultiplyMatrixByVector(m, r.Direction)
-	return New(origin, direction)
+	return NewAtTime(origin, direction, r.Time)
 }
 
 // New creates a new Ray struct.
 func New(origin, direction vector.Vector) Ray {
 	return Ray{Origin: origin, Direction: direction}
 }
+
+// NewAtTime creates a new Ray struct cast at time.
+func NewAtTime(origin, direction vector.Vector, time float64) Ray {
+	return Ray{Origin: origin, Direction: direction, Time: time}
+}
@@ -27,6 +27,16 @@ func TestRay(t *testing.T) {
 	}
 }
 
+func TestRayAtTime(t *testing.T) {
+	r := NewAtTime(vector.NewPoint(1, 2, 3), vector.NewVector(4, 5, 6), 0.25)
+	if r.Time != 0.25 {
+		t.Errorf("Ray time was %v, expected 0.25.", r.Time)
+	}
+	if r = New(vector.NewPoint(1, 2, 3), vector.NewVector(4, 5, 6)); r.Time != 0 {
+		t.Errorf("Ray time was %v, expected 0.", r.Time)
+	}
+}
+
 func TestPosition(t *testing.T) {
 	var tests = []struct {
 		ray      Ray
@@ -81,11 +91,18 @@ func TestTransformRay(t *testing.T) {
 			m:        matrix.ScalingMatrix(2, 3, 4),
 			expected: New(vector.NewPoint(2, 6, 12), vector.NewVector(0, 3, 0)),
 		},
+		{
+			// Transforming a ray keeps its time.
+			ray:      NewAtTime(vector.NewPoint(1, 2, 3), vector.NewVector(0, 1, 0), 0.5),
+			m:        matrix.TranslationMatrix(3, 4, 5),
+			expected: NewAtTime(vector.NewPoint(4, 6, 8), vector.NewVector(0, 1, 0), 0.5),
+		},
 	}
 	for _, test := range tests {
 		result := test.ray.Transform(test.m)
 		if vector.Equal(result.Origin, test.expected.Origin) != true ||
-			vector.Equal(result.Direction, test.expected.Direction) != true {
+			vector.Equal(result.Direction, test.expected.Direction) != true ||
+			result.Time != test.expected.Time {
 			t.Errorf(
 				"Transforming ray %+v with matrix %+v results in %+v, expected %+v.",
 				test.ray, test.m, result, test.expected,
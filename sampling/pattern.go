@@ -0,0 +1,79 @@
+package sampling
+
+import "math"
+
+// Offset is a position within a pixel, with each co-ordinate in [0, 1).
+type Offset struct {
+	X, Y float64
+}
+
+// Pattern is the interface for the ways of choosing sample positions in a pixel.
+type Pattern interface {
+	Offsets(n int) []Offset
+}
+
+// Grid places samples at the centres of a regular grid of cells.
+type Grid struct{}
+
+// Offsets returns n sample positions on a regular grid.
+func (g Grid) Offsets(n int) []Offset {
+	return cells(n, func() float64 { return 0.5 })
+}
+
+// Stratified places one sample at a random position in each cell of a regular
+// grid, so samples are jittered but evenly spread.
+type Stratified struct{}
+
+// Offsets returns n jittered sample positions.
+func (s Stratified) Offsets(n int) []Offset {
+	return cells(n, Float64)
+}
+
+// Random places samples uniformly at random in the pixel.
+type Random struct{}
+
+// Offsets returns n random sample positions.
+func (r Random) Offsets(n int) []Offset {
+	offsets := make([]Offset, n)
+	for i := range offsets {
+		offsets[i] = Offset{X: Float64(), Y: Float64()}
+	}
+	return offsets
+}
+
+// Shift returns offsets moved by (dx, dy) and wrapped back into the pixel,
+// which gives fresh positions from a pattern without losing how evenly it
+// spreads them.
+func Shift(offsets []Offset, dx, dy float64) []Offset {
+	shifted := make([]Offset, len(offsets))
+	for i, o := range offsets {
+		x, y := o.X+dx, o.Y+dy
+		shifted[i] = Offset{X: x - math.Floor(x), Y: y - math.Floor(y)}
+	}
+	return shifted
+}
+
+// cells divides the pixel into rows of n cells, which fill the width of every
+// row, and returns a position within each cell offset in each axis by the value
+// of jitter.
+func cells(n int, jitter func() float64) []Offset {
+	if n <= 0 {
+		return []Offset{}
+	}
+	columns := int(math.Ceil(math.Sqrt(float64(n))))
+	rows := (n + columns - 1) / columns
+	offsets := make([]Offset, n)
+	for i := range offsets {
+		x, y := i%columns, i/columns
+		// The last row may be short, so its cells are wider.
+		width := columns
+		if y == rows-1 {
+			width = n - columns*(rows-1)
+		}
+		offsets[i] = Offset{
+			X: (float64(x) + jitter()) / float64(width),
+			Y: (float64(y) + jitter()) / float64(rows),
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,118 @@
+package sampling
+
+import (
+	"testing"
+)
+
+func TestGrid(t *testing.T) {
+	var tests = []struct {
+		n        int
+		expected []Offset
+	}{
+		{n: 0, expected: []Offset{}},
+		{n: 1, expected: []Offset{{0.5, 0.5}}},
+		{n: 4, expected: []Offset{{0.25, 0.25}, {0.75, 0.25}, {0.25, 0.75}, {0.75, 0.75}}},
+		{n: 2, expected: []Offset{{0.25, 0.5}, {0.75, 0.5}}},
+		// Short last rows are spread across the pixel.
+		{n: 3, expected: []Offset{{0.25, 0.25}, {0.75, 0.25}, {0.5, 0.75}}},
+		{
+			n: 5,
+			expected: []Offset{
+				{0.5 / 3, 0.25}, {1.5 / 3, 0.25}, {2.5 / 3, 0.25}, {0.25, 0.75}, {0.75, 0.75},
+			},
+		},
+	}
+	for _, test := range tests {
+		result := Grid{}.Offsets(test.n)
+		if len(result) != len(test.expected) {
+			t.Fatalf("Grid of %d returned %d offsets.", test.n, len(result))
+		}
+		for i := range result {
+			if result[i] != test.expected[i] {
+				t.Errorf("Grid of %d returned %+v, expected %+v.", test.n, result, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestStratified(t *testing.T) {
+	// cell is the part of the pixel a sample must fall in.
+	type cell struct {
+		x0, x1, y0, y1 float64
+	}
+	var tests = []struct {
+		n     int
+		cells []cell
+	}{
+		{
+			n: 9,
+			cells: []cell{
+				{0, 1.0 / 3, 0, 1.0 / 3}, {1.0 / 3, 2.0 / 3, 0, 1.0 / 3}, {2.0 / 3, 1, 0, 1.0 / 3},
+				{0, 1.0 / 3, 1.0 / 3, 2.0 / 3}, {1.0 / 3, 2.0 / 3, 1.0 / 3, 2.0 / 3}, {2.0 / 3, 1, 1.0 / 3, 2.0 / 3},
+				{0, 1.0 / 3, 2.0 / 3, 1}, {1.0 / 3, 2.0 / 3, 2.0 / 3, 1}, {2.0 / 3, 1, 2.0 / 3, 1},
+			},
+		},
+		{
+			// The cells cover the whole pixel when the last row is short.
+			n:     3,
+			cells: []cell{{0, 0.5, 0, 0.5}, {0.5, 1, 0, 0.5}, {0, 1, 0.5, 1}},
+		},
+		{
+			n: 5,
+			cells: []cell{
+				{0, 1.0 / 3, 0, 0.5}, {1.0 / 3, 2.0 / 3, 0, 0.5}, {2.0 / 3, 1, 0, 0.5},
+				{0, 0.5, 0.5, 1}, {0.5, 1, 0.5, 1},
+			},
+		},
+	}
+	Seed(1)
+	for _, test := range tests {
+		result := Stratified{}.Offsets(test.n)
+		if len(result) != test.n {
+			t.Fatalf("Stratified of %d returned %d offsets.", test.n, len(result))
+		}
+		for i, o := range result {
+			c := test.cells[i]
+			if o.X < c.x0 || o.X >= c.x1 || o.Y < c.y0 || o.Y >= c.y1 {
+				t.Errorf("Stratified of %d offset %d was %+v, which is not in cell %+v.", test.n, i, o, c)
+			}
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	Seed(1)
+	result := Random{}.Offsets(16)
+	if len(result) != 16 {
+		t.Fatalf("Random of 16 returned %d offsets.", len(result))
+	}
+	for _, o := range result {
+		if o.X < 0 || o.X >= 1 || o.Y < 0 || o.Y >= 1 {
+			t.Errorf("Random offset %+v is outside the pixel.", o)
+		}
+	}
+}
+
+func TestShift(t *testing.T) {
+	offsets := []Offset{{0.25, 0.25}, {0.75, 0.75}}
+	var tests = []struct {
+		dx, dy   float64
+		expected []Offset
+	}{
+		{dx: 0, dy: 0, expected: []Offset{{0.25, 0.25}, {0.75, 0.75}}},
+		{dx: 0.125, dy: 0.5, expected: []Offset{{0.375, 0.75}, {0.875, 0.25}}},
+		{dx: 0.5, dy: 0.25, expected: []Offset{{0.75, 0.5}, {0.25, 0}}},
+	}
+	for _, test := range tests {
+		result := Shift(offsets, test.dx, test.dy)
+		for i := range result {
+			if result[i] != test.expected[i] {
+				t.Errorf(
+					"Shift by (%v, %v) moved offset %d to %+v, expected %+v.",
+					test.dx, test.dy, i, result[i], test.expected[i],
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sampling
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// UniformSpherePDF is the solid angle probability density of UniformSphere.
+const UniformSpherePDF = 1 / (4 * math.Pi)
+
+var source = rand.New(rand.NewSource(1))
+
+// Seed resets the random source used for sampling so that renders are repeatable.
+func Seed(seed int64) {
+	source.Seed(seed)
+}
+
+// Float64 returns a random number in the range [0, 1).
+func Float64() float64 {
+	return source.Float64()
+}
+
+// UniformSphere returns a direction uniformly distributed over the unit sphere for
+// the random numbers u1 and u2.
+func UniformSphere(u1, u2 float64) vector.Vector {
+	z := 1 - 2*u1
+	r := math.Sqrt(math.Max(0, 1-z*z))
+	phi := 2 * math.Pi * u2
+	return vector.NewVector(r*math.Cos(phi), r*math.Sin(phi), z)
+}
+
+// CosineHemisphere returns a direction in the hemisphere around the normal n,
+// distributed in proportion to the cosine of its angle with n.
+func CosineHemisphere(n vector.Vector, u1, u2 float64) vector.Vector {
+	r := math.Sqrt(u1)
+	phi := 2 * math.Pi * u2
+	return FromLocal(n, r*math.Cos(phi), r*math.Sin(phi), math.Sqrt(math.Max(0, 1-u1)))
+}
+
+// CosineHemispherePDF returns the solid angle probability density of
+// CosineHemisphere for a direction with the given cosine to the normal.
+func CosineHemispherePDF(cosTheta float64) float64 {
+	if cosTheta <= 0 {
+		return 0
+	}
+	return cosTheta / math.Pi
+}
+
+// FromLocal returns the direction with co-ordinates (x, y, z) in a frame where
+// the normal n is the z axis.
+func FromLocal(n vector.Vector, x, y, z float64) vector.Vector {
+	t, b := vector.Basis(n)
+	t = t.ScalarMultiply(x)
+	b = b.ScalarMultiply(y)
+	n = n.ScalarMultiply(z)
+	return vector.Add(vector.Add(t, b), n)
+}
+
+// UniformDisc returns a point uniformly distributed on the unit disc, using
+// Shirley's concentric mapping from the unit square.
+func UniformDisc(u1, u2 float64) (float64, float64) {
+	a, b := 2*u1-1, 2*u2-1
+	if a == 0 && b == 0 {
+		return 0, 0
+	}
+	var r, theta float64
+	if math.Abs(a) > math.Abs(b) {
+		r = a
+		theta = math.Pi / 4 * (b / a)
+	} else {
+		r = b
+		theta = math.Pi/2 - math.Pi/4*(a/b)
+	}
+	return r * math.Cos(theta), r * math.Sin(theta)
+}
+
+// UniformPolygon returns a point uniformly distributed on the regular polygon
+// with the given number of sides, inscribed in the unit circle with a vertex at
+// angle rotation.
+func UniformPolygon(sides int, rotation, u1, u2 float64) (float64, float64) {
+	// Choose a triangle between the centre and one side, reusing the remainder
+	// of u1 within it.
+	u1 *= float64(sides)
+	side := math.Min(math.Floor(u1), float64(sides-1))
+	u1 -= side
+	a0 := rotation + 2*math.Pi*side/float64(sides)
+	a1 := rotation + 2*math.Pi*(side+1)/float64(sides)
+	su := math.Sqrt(u1)
+	b0, b1 := su*(1-u2), su*u2
+	return b0*math.Cos(a0) + b1*math.Cos(a1), b0*math.Sin(a0) + b1*math.Sin(a1)
+}
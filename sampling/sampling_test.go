@@ -0,0 +1,123 @@
+package sampling
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestSeed(t *testing.T) {
+	Seed(7)
+	a := Float64()
+	Seed(7)
+	b := Float64()
+	if a != b {
+		t.Errorf("Seeding produced %v then %v, expected the same value.", a, b)
+	}
+}
+
+func TestUniformSphere(t *testing.T) {
+	var tests = []struct {
+		u1, u2   float64
+		expected vector.Vector
+	}{
+		{u1: 0, u2: 0, expected: vector.NewVector(0, 0, 1)},
+		{u1: 1, u2: 0, expected: vector.NewVector(0, 0, -1)},
+		{u1: 0.5, u2: 0.25, expected: vector.NewVector(0, 1, 0)},
+	}
+	for _, test := range tests {
+		result := UniformSphere(test.u1, test.u2)
+		if vector.Equal(result, test.expected) != true {
+			t.Errorf(
+				"UniformSphere(%v, %v) was %+v, expected %+v.",
+				test.u1, test.u2, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestCosineHemisphere(t *testing.T) {
+	n := vector.NewVector(0, 1, 0)
+	for i := 0; i < 100; i++ {
+		d := CosineHemisphere(n, Float64(), Float64())
+		if comparison.EpsilonEqual(d.Magnitude(), 1) != true {
+			t.Errorf("CosineHemisphere returned %+v, which is not normalized.", d)
+		}
+		if vector.DotProduct(d, n) < 0 {
+			t.Errorf("CosineHemisphere returned %+v, which is below the normal.", d)
+		}
+	}
+	d := CosineHemisphere(n, 0, 0)
+	if vector.Equal(d, n) != true {
+		t.Errorf("CosineHemisphere(%+v, 0, 0) was %+v, expected the normal.", n, d)
+	}
+}
+
+func TestCosineHemispherePDF(t *testing.T) {
+	var tests = []struct {
+		cosTheta, expected float64
+	}{
+		{cosTheta: 1, expected: 1 / math.Pi},
+		{cosTheta: 0.5, expected: 0.5 / math.Pi},
+		{cosTheta: -0.5, expected: 0},
+	}
+	for _, test := range tests {
+		result := CosineHemispherePDF(test.cosTheta)
+		if comparison.EpsilonEqual(result, test.expected) != true {
+			t.Errorf(
+				"CosineHemispherePDF(%v) was %v, expected %v.",
+				test.cosTheta, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestUniformDisc(t *testing.T) {
+	var tests = []struct {
+		u1, u2, x, y float64
+	}{
+		{u1: 0.5, u2: 0.5, x: 0, y: 0},
+		{u1: 1, u2: 0.5, x: 1, y: 0},
+		{u1: 0.5, u2: 0, x: 0, y: -1},
+		{u1: 0.75, u2: 0.5, x: 0.5, y: 0},
+	}
+	for _, test := range tests {
+		x, y := UniformDisc(test.u1, test.u2)
+		if comparison.EpsilonEqual(x, test.x) != true || comparison.EpsilonEqual(y, test.y) != true {
+			t.Errorf(
+				"UniformDisc(%v, %v) was (%v, %v), expected (%v, %v).",
+				test.u1, test.u2, x, y, test.x, test.y,
+			)
+		}
+	}
+	Seed(1)
+	for i := 0; i < 100; i++ {
+		x, y := UniformDisc(Float64(), Float64())
+		if x*x+y*y > 1+comparison.EPSLION {
+			t.Errorf("UniformDisc returned (%v, %v), outside the disc.", x, y)
+		}
+	}
+}
+
+func TestUniformPolygon(t *testing.T) {
+	Seed(1)
+	for sides := 3; sides < 9; sides++ {
+		// The apothem is the distance from the centre to the middle of a side.
+		apothem := math.Cos(math.Pi / float64(sides))
+		for i := 0; i < 200; i++ {
+			x, y := UniformPolygon(sides, 0.3, Float64(), Float64())
+			for side := 0; side < sides; side++ {
+				angle := 0.3 + 2*math.Pi*(float64(side)+0.5)/float64(sides)
+				if x*math.Cos(angle)+y*math.Sin(angle) > apothem+comparison.EPSLION {
+					t.Fatalf("UniformPolygon(%d) returned (%v, %v), outside the polygon.", sides, x, y)
+				}
+			}
+		}
+	}
+	x, y := UniformPolygon(4, 0, 0, 0)
+	if comparison.EpsilonEqual(x, 0) != true || comparison.EpsilonEqual(y, 0) != true {
+		t.Errorf("UniformPolygon(4, 0, 0, 0) was (%v, %v), expected the centre.", x, y)
+	}
+}
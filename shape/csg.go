@@ -0,0 +1,219 @@
+package shape
+
+import (
+	"github.com/lukeshiner/raytrace/matrix"
+	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Operation is the type for the ways CSG shapes combine their children.
+type Operation int
+
+const (
+	// CSGUnion is everywhere inside either child.
+	CSGUnion Operation = iota
+	// CSGIntersection is everywhere inside both children.
+	CSGIntersection
+	// CSGDifference is everywhere inside the left child but not the right.
+	CSGDifference
+)
+
+// allowed returns true if an intersection with the left child when leftHit is
+// true, otherwise the right, is on the surface of the combined shape, when it
+// is inside the left child if inLeft is true and the right if inRight is true.
+func (o Operation) allowed(leftHit, inLeft, inRight bool) bool {
+	switch o {
+	case CSGUnion:
+		return (leftHit && !inRight) || (!leftHit && !inLeft)
+	case CSGIntersection:
+		return (leftHit && inRight) || (!leftHit && inLeft)
+	case CSGDifference:
+		return (leftHit && !inRight) || (!leftHit && inLeft)
+	}
+	return false
+}
+
+// CSG is a shape made by combining two closed shapes, Left and Right, which may
+// be CSG shapes themselves. The children are placed by the CSG shape's transform
+// and their own. Hits are on the children, so have their materials, but the
+// normals of the right child of a difference are turned to point out of it, and
+// each flag, such as CastsShadows, is only true of a child if it is true of the
+// CSG shape too.
+type CSG struct {
+	shape
+	Operation   Operation
+	Left, Right Shape
+}
+
+// LocalIntersect returns the intersections of a ray with the children which are
+// on the surface of the combined shape.
+func (s *CSG) LocalIntersect(r ray.Ray) Intersections {
+	s.SaveRay(r)
+	intersections := CombineIntersections(Intersect(s.Left, r), Intersect(s.Right, r))
+	filtered := s.filter(intersections)
+	for i, intersection := range filtered.Intersections {
+		flipped := s.Operation == CSGDifference && !includes(s.Left, intersection.Object.ID())
+		filtered.Intersections[i].Object = place(intersection.Object, s, flipped)
+	}
+	return filtered
+}
+
+// filter returns the intersections with the children, in order, which are on
+// the surface of the combined shape.
+func (s *CSG) filter(intersections Intersections) Intersections {
+	inLeft, inRight := false, false
+	filtered := NewIntersections()
+	for _, i := range intersections.Intersections {
+		leftHit := includes(s.Left, i.Object.ID())
+		if s.Operation.allowed(leftHit, inLeft, inRight) {
+			filtered.Intersections = append(filtered.Intersections, i)
+		}
+		if leftHit {
+			inLeft = !inLeft
+		} else {
+			inRight = !inRight
+		}
+	}
+	return filtered
+}
+
+// Includes returns true if the shape with id is one of the shapes the CSG shape
+// is made from.
+func (s *CSG) Includes(id int) bool {
+	return includes(s.Left, id) || includes(s.Right, id)
+}
+
+// includes returns true if s is, or is made from, the shape with id.
+func includes(s Shape, id int) bool {
+	if csg, ok := s.(*CSG); ok {
+		return csg.Includes(id)
+	}
+	return s.ID() == id
+}
+
+// NewCSG returns a shape combining left and right by an operation.
+func NewCSG(operation Operation, left, right Shape) Shape {
+	return &CSG{shape: newShape(), Operation: operation, Left: left, Right: right}
+}
+
+// Solid returns the whole object whose surface s is part of: the outermost CSG
+// shape for a child of one in an intersection, otherwise s.
+func Solid(s Shape) Shape {
+	switch p := s.(type) {
+	case placed:
+		return p.parent
+	case placedMapper:
+		return p.parent
+	}
+	return s
+}
+
+// placed is a shape inside another, such as a child of a CSG shape, with the
+// transforms of both. When flipped is true its normals are reversed.
+type placed struct {
+	Shape
+	parent  Shape
+	flipped bool
+	// transform is the shape's transform inside its parent's, and inverse its
+	// inverse. moving is true if either shape moves.
+	transform, inverse matrix.Matrix
+	moving             bool
+}
+
+// place returns s inside parent, keeping its texture co-ordinates.
+func place(s Shape, parent Shape, flipped bool) Shape {
+	transform := matrix.Multiply(parent.Transform(), s.Transform())
+	inverse, _ := transform.Invert()
+	moving := !matrix.Equal(parent.EndTransform(), parent.Transform()) ||
+		!matrix.Equal(s.EndTransform(), s.Transform())
+	p := placed{
+		Shape: s, parent: parent, flipped: flipped, transform: transform, inverse: inverse, moving: moving,
+	}
+	if _, ok := s.(UVMapper); ok {
+		return placedMapper{p}
+	}
+	return p
+}
+
+// Transform returns the shape's transform inside its parent's.
+func (s placed) Transform() matrix.Matrix {
+	return s.transform
+}
+
+// InverseTransform returns the inverse of the shape's transform.
+func (s placed) InverseTransform() matrix.Matrix {
+	return s.inverse
+}
+
+// EndTransform returns the shape's transform at the end of its motion.
+func (s placed) EndTransform() matrix.Matrix {
+	return matrix.Multiply(s.parent.EndTransform(), s.Shape.EndTransform())
+}
+
+// TransformAt returns the shape's transform at time.
+func (s placed) TransformAt(time float64) matrix.Matrix {
+	if !s.moving || time == 0 {
+		return s.transform
+	}
+	return matrix.Multiply(s.parent.TransformAt(time), s.Shape.TransformAt(time))
+}
+
+// InverseTransformAt returns the inverse of the shape's transform at time.
+func (s placed) InverseTransformAt(time float64) matrix.Matrix {
+	if !s.moving || time == 0 {
+		return s.inverse
+	}
+	t, _ := s.TransformAt(time).Invert()
+	return t
+}
+
+// CastsShadows returns true if both the shape and its parent cast shadows.
+func (s placed) CastsShadows() bool {
+	return s.Shape.CastsShadows() && s.parent.CastsShadows()
+}
+
+// ReceivesShadows returns true if both the shape and its parent receive shadows.
+func (s placed) ReceivesShadows() bool {
+	return s.Shape.ReceivesShadows() && s.parent.ReceivesShadows()
+}
+
+// VisibleToCamera returns true if both the shape and its parent are visible to
+// the camera.
+func (s placed) VisibleToCamera() bool {
+	return s.Shape.VisibleToCamera() && s.parent.VisibleToCamera()
+}
+
+// VisibleInReflections returns true if both the shape and its parent are
+// visible in reflections and refractions.
+func (s placed) VisibleInReflections() bool {
+	return s.Shape.VisibleInReflections() && s.parent.VisibleInReflections()
+}
+
+// LocalNormalAt returns the normal of the shape at a point in local space.
+func (s placed) LocalNormalAt(p vector.Vector) vector.Vector {
+	n := s.Shape.LocalNormalAt(p)
+	if s.flipped {
+		return n.Negate()
+	}
+	return n
+}
+
+// placedMapper is a placed shape with texture co-ordinates.
+type placedMapper struct {
+	placed
+}
+
+// LocalUV returns the texture co-ordinates of the shape at a point.
+func (s placedMapper) LocalUV(p vector.Vector) (float64, float64) {
+	return s.Shape.(UVMapper).LocalUV(p)
+}
+
+// LocalTangent returns the tangent of the shape at a point.
+func (s placedMapper) LocalTangent(p vector.Vector) vector.Vector {
+	return s.Shape.(UVMapper).LocalTangent(p)
+}
+
+// LocalBitangent returns the bitangent of the shape at a point.
+func (s placedMapper) LocalBitangent(p vector.Vector) vector.Vector {
+	return s.Shape.(UVMapper).LocalBitangent(p)
+}
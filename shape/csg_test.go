@@ -0,0 +1,243 @@
+package shape
+
+import (
+	"testing"
+
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/matrix"
+	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestOperationAllowed(t *testing.T) {
+	var tests = []struct {
+		operation                Operation
+		leftHit, inLeft, inRight bool
+		expected                 bool
+	}{
+		{operation: CSGUnion, leftHit: true, inLeft: true, inRight: true, expected: false},
+		{operation: CSGUnion, leftHit: true, inLeft: true, inRight: false, expected: true},
+		{operation: CSGUnion, leftHit: true, inLeft: false, inRight: true, expected: false},
+		{operation: CSGUnion, leftHit: true, inLeft: false, inRight: false, expected: true},
+		{operation: CSGUnion, leftHit: false, inLeft: true, inRight: true, expected: false},
+		{operation: CSGUnion, leftHit: false, inLeft: true, inRight: false, expected: false},
+		{operation: CSGUnion, leftHit: false, inLeft: false, inRight: true, expected: true},
+		{operation: CSGUnion, leftHit: false, inLeft: false, inRight: false, expected: true},
+		{operation: CSGIntersection, leftHit: true, inLeft: true, inRight: true, expected: true},
+		{operation: CSGIntersection, leftHit: true, inLeft: true, inRight: false, expected: false},
+		{operation: CSGIntersection, leftHit: true, inLeft: false, inRight: true, expected: true},
+		{operation: CSGIntersection, leftHit: true, inLeft: false, inRight: false, expected: false},
+		{operation: CSGIntersection, leftHit: false, inLeft: true, inRight: true, expected: true},
+		{operation: CSGIntersection, leftHit: false, inLeft: true, inRight: false, expected: true},
+		{operation: CSGIntersection, leftHit: false, inLeft: false, inRight: true, expected: false},
+		{operation: CSGIntersection, leftHit: false, inLeft: false, inRight: false, expected: false},
+		{operation: CSGDifference, leftHit: true, inLeft: true, inRight: true, expected: false},
+		{operation: CSGDifference, leftHit: true, inLeft: true, inRight: false, expected: true},
+		{operation: CSGDifference, leftHit: true, inLeft: false, inRight: true, expected: false},
+		{operation: CSGDifference, leftHit: true, inLeft: false, inRight: false, expected: true},
+		{operation: CSGDifference, leftHit: false, inLeft: true, inRight: true, expected: true},
+		{operation: CSGDifference, leftHit: false, inLeft: true, inRight: false, expected: true},
+		{operation: CSGDifference, leftHit: false, inLeft: false, inRight: true, expected: false},
+		{operation: CSGDifference, leftHit: false, inLeft: false, inRight: false, expected: false},
+	}
+	for _, test := range tests {
+		result := test.operation.allowed(test.leftHit, test.inLeft, test.inRight)
+		if result != test.expected {
+			t.Errorf(
+				"Operation %v with a left hit %v, inside left %v and inside right %v was allowed %v, expected %v.",
+				test.operation, test.leftHit, test.inLeft, test.inRight, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestCSGFilter(t *testing.T) {
+	left, right := NewSphere(), NewSphere()
+	intersections := NewIntersections(
+		NewIntersection(1, left), NewIntersection(2, right),
+		NewIntersection(3, left), NewIntersection(4, right),
+	)
+	var tests = []struct {
+		operation Operation
+		expected  []float64
+	}{
+		{operation: CSGUnion, expected: []float64{1, 4}},
+		{operation: CSGIntersection, expected: []float64{2, 3}},
+		{operation: CSGDifference, expected: []float64{1, 2}},
+	}
+	for _, test := range tests {
+		csg := NewCSG(test.operation, left, right).(*CSG)
+		result := csg.filter(intersections)
+		if comparison.EqualSlice(result.TSlice(), test.expected) != true {
+			t.Errorf("CSG %v filtered to %v, expected %v.", test.operation, result.TSlice(), test.expected)
+		}
+	}
+}
+
+func TestCSGIntersect(t *testing.T) {
+	left := NewSphere()
+	right := NewSphere()
+	right.SetTransform(matrix.TranslationMatrix(0, 0, 0.5))
+	inner := NewSphere()
+	inner.SetTransform(matrix.ScalingMatrix(0.5, 0.5, 0.5))
+	moved := NewCSG(CSGUnion, left, right)
+	moved.SetTransform(matrix.TranslationMatrix(5, 0, 0))
+	var tests = []struct {
+		csg      Shape
+		ray      ray.Ray
+		expected []float64
+		objects  []Shape
+	}{
+		{
+			csg: NewCSG(CSGUnion, left, right),
+			ray: ray.New(vector.NewPoint(0, 2, -5), vector.NewVector(0, 0, 1)),
+		},
+		{
+			csg:      NewCSG(CSGUnion, left, right),
+			ray:      ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1)),
+			expected: []float64{4, 6.5}, objects: []Shape{left, right},
+		},
+		{
+			csg:      NewCSG(CSGIntersection, left, right),
+			ray:      ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1)),
+			expected: []float64{4.5, 6}, objects: []Shape{right, left},
+		},
+		{
+			csg:      NewCSG(CSGDifference, left, right),
+			ray:      ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1)),
+			expected: []float64{4, 4.5}, objects: []Shape{left, right},
+		},
+		{
+			// Children are placed by the CSG shape's transform.
+			csg:      moved,
+			ray:      ray.New(vector.NewPoint(5, 0, -5), vector.NewVector(0, 0, 1)),
+			expected: []float64{4, 6.5}, objects: []Shape{left, right},
+		},
+		{
+			// CSG shapes can be made from CSG shapes.
+			csg:      NewCSG(CSGDifference, NewCSG(CSGUnion, left, right), inner),
+			ray:      ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1)),
+			expected: []float64{4, 4.5, 5.5, 6.5}, objects: []Shape{left, inner, inner, right},
+		},
+	}
+	for _, test := range tests {
+		result := Intersect(test.csg, test.ray)
+		if comparison.EqualSlice(result.TSlice(), test.expected) != true {
+			t.Errorf("Intersections with CSG were %v, expected %v.", result.TSlice(), test.expected)
+			continue
+		}
+		for i, object := range test.objects {
+			if comparison.EpsilonEqual(result.Get(i).T, test.expected[i]) != true {
+				t.Errorf("Intersection %v with CSG was at %v, expected %v.", i, result.Get(i).T, test.expected[i])
+			}
+			if result.Get(i).Object.ID() != object.ID() {
+				t.Errorf(
+					"Intersection %v with CSG was with object %v, expected %v.",
+					i, result.Get(i).Object.ID(), object.ID(),
+				)
+			}
+		}
+	}
+}
+
+func TestCSGChildNormal(t *testing.T) {
+	left := NewSphere()
+	right := NewSphere()
+	right.SetTransform(matrix.TranslationMatrix(0, 0, 0.5))
+	csg := NewCSG(CSGDifference, left, right)
+	csg.SetTransform(matrix.TranslationMatrix(5, 0, 0))
+	r := ray.New(vector.NewPoint(5, 0, -5), vector.NewVector(0, 0, 1))
+	intersections := Intersect(csg, r)
+	var tests = []struct {
+		point, normal vector.Vector
+		u, v          float64
+	}{
+		{point: vector.NewPoint(5, 0, -1), normal: vector.NewVector(0, 0, -1), u: 0, v: 0.5},
+		// The hole cut by the right sphere faces out of the difference.
+		{point: vector.NewPoint(5, 0, -0.5), normal: vector.NewVector(0, 0, 1), u: 0, v: 0.5},
+	}
+	for i, test := range tests {
+		object := intersections.Get(i).Object
+		if point := r.Position(intersections.Get(i).T); !vector.Equal(point, test.point) {
+			t.Errorf("CSG hit %v was at %v, expected %v.", i, point, test.point)
+		}
+		if normal := NormalAt(object, test.point); !vector.Equal(normal, test.normal) {
+			t.Errorf("Normal of CSG hit %v was %v, expected %v.", i, normal, test.normal)
+		}
+		u, v, ok := UVAtTime(object, test.point, 0)
+		if !ok || comparison.EpsilonEqual(u, test.u) != true || comparison.EpsilonEqual(v, test.v) != true {
+			t.Errorf("UV of CSG hit %v was (%v, %v, %v), expected (%v, %v).", i, u, v, ok, test.u, test.v)
+		}
+	}
+}
+
+func TestSolid(t *testing.T) {
+	sphere := NewSphere()
+	inner := NewCSG(CSGUnion, NewSphere(), NewSphere())
+	outer := NewCSG(CSGUnion, inner, NewSphere())
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	outerHits, innerHits := Intersect(outer, r), Intersect(inner, r)
+	var tests = []struct {
+		object, expected Shape
+	}{
+		{object: sphere, expected: sphere},
+		{object: outer, expected: outer},
+		// Hits on children of nested CSG shapes are on the outermost one.
+		{object: outerHits.Get(0).Object, expected: outer},
+		{object: innerHits.Get(0).Object, expected: inner},
+	}
+	for _, test := range tests {
+		if result := Solid(test.object); result.ID() != test.expected.ID() {
+			t.Errorf("Solid of %+v was %+v, expected %+v.", test.object, result, test.expected)
+		}
+	}
+}
+
+func TestPlacedTransform(t *testing.T) {
+	still := NewSphere()
+	still.SetTransform(matrix.ScalingMatrix(2, 2, 2))
+	moving := NewSphere()
+	moving.SetEndTransform(matrix.TranslationMatrix(0, 2, 0))
+	csg := NewCSG(CSGUnion, still, moving)
+	csg.SetTransform(matrix.TranslationMatrix(1, 0, 0))
+	var tests = []struct {
+		child     Shape
+		time      float64
+		transform matrix.Matrix
+	}{
+		{
+			child: still, time: 0.5,
+			transform: matrix.Multiply(matrix.TranslationMatrix(1, 0, 0), matrix.ScalingMatrix(2, 2, 2)),
+		},
+		{child: moving, time: 0, transform: matrix.TranslationMatrix(1, 0, 0)},
+		{child: moving, time: 0.5, transform: matrix.TranslationMatrix(1, 1, 0)},
+	}
+	for _, test := range tests {
+		p := place(test.child, csg, false)
+		inverse, _ := test.transform.Invert()
+		if result := p.TransformAt(test.time); matrix.Equal(result, test.transform) != true {
+			t.Errorf("Transform of a placed shape at %v was %v, expected %v.", test.time, result, test.transform)
+		}
+		if result := p.InverseTransformAt(test.time); matrix.Equal(result, inverse) != true {
+			t.Errorf("Inverse transform of a placed shape at %v was %v, expected %v.", test.time, result, inverse)
+		}
+	}
+}
+
+func TestCSGChildFlags(t *testing.T) {
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	for _, test := range flagTests {
+		inner := NewCSG(CSGUnion, NewSphere(), NewSphere())
+		outer := NewCSG(CSGUnion, inner, NewSphere())
+		intersections := Intersect(outer, r)
+		if test.get(intersections.Get(0).Object) != true {
+			t.Errorf("CSG child %s was not true by default.", test.name)
+		}
+		// Flags of CSG shapes inside others apply to their children too.
+		test.set(inner, false)
+		intersections = Intersect(outer, r)
+		if test.get(intersections.Get(0).Object) != false {
+			t.Errorf("CSG child %s was true when its parent's was false.", test.name)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math"
 	"sort"
 
+	"github.com/lukeshiner/raytrace/bsdf"
 	"github.com/lukeshiner/raytrace/colour"
 	"github.com/lukeshiner/raytrace/light"
 	"github.com/lukeshiner/raytrace/material"
@@ -83,34 +84,52 @@ func CombineIntersections(intersections ...Intersections) Intersections {
 	return ins
 }
 
-// Lighting calculates the lighting on a surface
+// Lighting calculates the lighting on a surface, from its ambient colour and
+// the light its material's BSDF reflects.
 func Lighting(
 	m material.Material, l light.Light, p, e, n vector.Vector, inShadow bool,
 ) colour.Colour {
-	var diffuse, specular colour.Colour
+	return SurfaceLighting(m, m.Surface(), l, p, e, n, inShadow)
+}
+
+// SurfaceLighting calculates the lighting on a surface, from the ambient colour
+// of its material and the light surface reflects.
+func SurfaceLighting(
+	m material.Material, surface bsdf.BSDF, l light.Light, p, e, n vector.Vector, inShadow bool,
+) colour.Colour {
+	transmittance := colour.New(1, 1, 1)
+	if inShadow {
+		transmittance = colour.New(0, 0, 0)
+	}
+	return FilteredLighting(m, surface, l, p, e, n, transmittance)
+}
+
+// FilteredLighting calculates the lighting on a surface like SurfaceLighting,
+// when transmittance is the fraction of the light which reaches it.
+func FilteredLighting(
+	m material.Material, surface bsdf.BSDF, l light.Light, p, e, n vector.Vector,
+	transmittance colour.Colour,
+) colour.Colour {
 	effectiveColour := m.Colour.Mult(l.Intensity())
-	lightVector := vector.Subtract(l.Position(), p)
-	lightVector = lightVector.Normalize()
+	lightVector, _ := l.DirectionFrom(p)
 	ambient := effectiveColour.ScalarMult(m.Ambient)
 	lightDotNormal := vector.DotProduct(lightVector, n)
-	if inShadow || lightDotNormal < 0 {
+	if transmittance == colour.New(0, 0, 0) || lightDotNormal < 0 {
 		// Light behind surface
-		diffuse = colour.New(0, 0, 0)
-		specular = colour.New(0, 0, 0)
+		return ambient
+	}
+	var reflected colour.Colour
+	if phong, ok := surface.(bsdf.Phong); ok {
+		// Lighting keeps the highlight of the Ray Tracer Challenge, which is not
+		// scaled by the angle of the light.
+		highlight := phong.Highlight(lightVector, e, n)
+		phong.Specular = 0
+		reflected = phong.Eval(lightVector, e, n).ScalarMult(math.Pi * lightDotNormal).
+			Add(colour.New(highlight, highlight, highlight))
 	} else {
-		diffuse = effectiveColour.ScalarMult(m.Diffuse * lightDotNormal)
-		reflectVector := Reflect(lightVector.Negate(), n)
-		reflectDotEye := vector.DotProduct(reflectVector, e)
-		if reflectDotEye <= 0 {
-			// Light reflects away from eye
-			specular = colour.New(0, 0, 0)
-		} else {
-			factor := math.Pow(reflectDotEye, m.Shininess)
-			specular = l.Intensity().ScalarMult(m.Specular * factor)
-		}
+		reflected = surface.Eval(lightVector, e, n).ScalarMult(math.Pi * lightDotNormal)
 	}
-
-	return ambient.Add(diffuse.Add(specular))
+	return ambient.Add(reflected.Mult(l.Intensity()).Mult(transmittance))
 }
 
 // Reflect returns the reflection of a vector around a normal.
@@ -4,6 +4,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/lukeshiner/raytrace/bsdf"
 	"github.com/lukeshiner/raytrace/colour"
 	"github.com/lukeshiner/raytrace/comparison"
 	"github.com/lukeshiner/raytrace/light"
@@ -256,6 +257,16 @@ func TestLighting(t *testing.T) {
 			inShadow: false,
 			expected: colour.New(1.6364, 1.6364, 1.6364),
 		},
+		{
+			// Lighting with a directional light shining onto the surface.
+			material: material.New(),
+			position: vector.NewPoint(0, 0, 0),
+			light:    light.NewDirectional(colour.New(1, 1, 1), vector.NewVector(0, 0, 1)),
+			normal:   vector.NewVector(0, 0, -1),
+			eye:      vector.NewVector(0, 0, -1),
+			inShadow: false,
+			expected: colour.New(1.9, 1.9, 1.9),
+		},
 		{
 			// Lighting with the light behind the surface.
 			material: material.New(),
@@ -286,6 +297,52 @@ func TestLighting(t *testing.T) {
 			inShadow: true,
 			expected: colour.New(0.1, 0.1, 0.1),
 		},
+		{
+			// Lighting a rough white dielectric head on.
+			material: material.NewMetallicRoughness(colour.New(1, 1, 1), 0, 1),
+			position: vector.NewPoint(0, 0, 0),
+			light:    light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, -10)),
+			normal:   vector.NewVector(0, 0, -1),
+			eye:      vector.NewVector(0, 0, -1),
+			inShadow: false,
+			expected: colour.New(1.07, 1.07, 1.07),
+		},
+		{
+			// Lighting a rough surface head on, which is darker than Lambertian.
+			material: material.Material{
+				Colour: colour.New(1, 1, 1), Ambient: 0.1, Diffuse: 0.9, Specular: 0.9,
+				Shininess: 200, DiffuseRoughness: 1,
+			},
+			position: vector.NewPoint(0, 0, 0),
+			light:    light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, -10)),
+			normal:   vector.NewVector(0, 0, -1),
+			eye:      vector.NewVector(0, 0, -1),
+			inShadow: false,
+			expected: colour.New(1.56165, 1.56165, 1.56165),
+		},
+		{
+			// Lighting with a BSDF set on the material.
+			material: material.Material{
+				Colour: colour.New(1, 1, 1), Ambient: 0.1,
+				BSDF: bsdf.Lambert{Albedo: colour.New(0.5, 0.5, 0.5)},
+			},
+			position: vector.NewPoint(0, 0, 0),
+			light:    light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, -10)),
+			normal:   vector.NewVector(0, 0, -1),
+			eye:      vector.NewVector(0, 0, -1),
+			inShadow: false,
+			expected: colour.New(0.6, 0.6, 0.6),
+		},
+		{
+			// Lighting a metallic roughness surface in shadow.
+			material: material.NewMetallicRoughness(colour.New(1, 0.5, 0), 1, 0.2),
+			position: vector.NewPoint(0, 0, 0),
+			light:    light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, -10)),
+			normal:   vector.NewVector(0, 0, -1),
+			eye:      vector.NewVector(0, 0, -1),
+			inShadow: true,
+			expected: colour.New(0.1, 0.05, 0),
+		},
 	}
 	for _, test := range tests {
 		result := Lighting(
@@ -301,6 +358,28 @@ func TestLighting(t *testing.T) {
 	}
 }
 
+func TestFilteredLighting(t *testing.T) {
+	var tests = []struct {
+		transmittance, expected colour.Colour
+	}{
+		{transmittance: colour.New(1, 1, 1), expected: colour.New(1.9, 1.9, 1.9)},
+		{transmittance: colour.New(1, 0.5, 0), expected: colour.New(1.9, 1.0, 0.1)},
+		{transmittance: colour.New(0, 0, 0), expected: colour.New(0.1, 0.1, 0.1)},
+	}
+	for _, test := range tests {
+		m := material.New()
+		l := light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, -10))
+		n := vector.NewVector(0, 0, -1)
+		result := FilteredLighting(m, m.Surface(), l, vector.NewPoint(0, 0, 0), n, n, test.transmittance)
+		if result.Equal(test.expected) != true {
+			t.Errorf(
+				"Lighting through %v resulted with %v, expected %v.",
+				test.transmittance, result, test.expected,
+			)
+		}
+	}
+}
+
 func TestAddIntersections(t *testing.T) {
 	o1 := NewSphere()
 	o2 := NewSphere()
@@ -0,0 +1,50 @@
+package shape
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// AreaSampler is implemented by shapes whose surfaces can be sampled, so they
+// can light the world when they are emissive.
+type AreaSampler interface {
+	// LocalSample returns a point on the shape and its normal in local space,
+	// and the probability density by area of choosing it.
+	LocalSample(u1, u2 float64) (vector.Vector, vector.Vector, float64)
+}
+
+// LocalSample returns a point chosen uniformly on the sphere.
+func (s Sphere) LocalSample(u1, u2 float64) (vector.Vector, vector.Vector, float64) {
+	n := sampling.UniformSphere(u1, u2)
+	return vector.NewPoint(n.X, n.Y, n.Z), n, 1 / (4 * math.Pi)
+}
+
+// LocalSample returns a point chosen uniformly on the triangle.
+func (s Triangle) LocalSample(u1, u2 float64) (vector.Vector, vector.Vector, float64) {
+	su := math.Sqrt(u1)
+	e1, e2 := s.E1.ScalarMultiply(su*(1-u2)), s.E2.ScalarMultiply(su*u2)
+	p := vector.Add(s.P1, vector.Add(e1, e2))
+	cross := vector.CrossProduct(s.E1, s.E2)
+	return p, s.Normal, 2 / cross.Magnitude()
+}
+
+// SampleSurface returns a point on a shape placed where it is at time, its
+// normal and the probability density by area of choosing it. ok is false if the
+// shape cannot be sampled.
+func SampleSurface(s Shape, u1, u2, time float64) (p, n vector.Vector, pdf float64, ok bool) {
+	sampler, ok := s.(AreaSampler)
+	if !ok {
+		return p, n, 0, false
+	}
+	local, localNormal, pdf := sampler.LocalSample(u1, u2)
+	m := s.TransformAt(time)
+	p = vector.MultiplyMatrixByVector(m, local)
+	// The transform scales the area around the point by the area of the
+	// parallelogram made from a pair of unit tangents.
+	t1, t2 := vector.Basis(localNormal)
+	area := vector.CrossProduct(
+		vector.MultiplyMatrixByVector(m, t1), vector.MultiplyMatrixByVector(m, t2))
+	return p, NormalAtTime(s, p, time), pdf / area.Magnitude(), true
+}
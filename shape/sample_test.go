@@ -0,0 +1,56 @@
+package shape
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/matrix"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestSampleSurface(t *testing.T) {
+	scaled := NewSphere()
+	scaled.SetTransform(matrix.Multiply(
+		matrix.TranslationMatrix(0, 3, 0), matrix.ScalingMatrix(2, 2, 2)))
+	triangle := NewTriangle(
+		vector.NewPoint(0, 0, 0), vector.NewPoint(0, 0, 2), vector.NewPoint(3, 0, 0))
+	var tests = []struct {
+		shape  Shape
+		ok     bool
+		pdf    float64
+		centre vector.Vector
+		radius float64
+	}{
+		{shape: NewSphere(), ok: true, pdf: 1 / (4 * math.Pi), centre: vector.NewPoint(0, 0, 0), radius: 1},
+		{shape: scaled, ok: true, pdf: 1 / (16 * math.Pi), centre: vector.NewPoint(0, 3, 0), radius: 2},
+		{shape: triangle, ok: true, pdf: 1.0 / 3},
+		{shape: NewPlane(), ok: false},
+	}
+	for _, test := range tests {
+		for _, u := range [][2]float64{{0.1, 0.2}, {0.5, 0.5}, {0.9, 0.7}} {
+			p, n, pdf, ok := SampleSurface(test.shape, u[0], u[1], 0)
+			if ok != test.ok {
+				t.Errorf("Sampling %T gave ok %v, expected %v.", test.shape, ok, test.ok)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if comparison.EpsilonEqual(pdf, test.pdf) != true {
+				t.Errorf("Sampling %T gave pdf %v, expected %v.", test.shape, pdf, test.pdf)
+			}
+			if !vector.Equal(n, NormalAt(test.shape, p)) {
+				t.Errorf("Sampling %T gave normal %v at %v, expected %v.", test.shape, n, p, NormalAt(test.shape, p))
+			}
+			if test.radius > 0 {
+				d := vector.Subtract(p, test.centre)
+				if comparison.EpsilonEqual(d.Magnitude(), test.radius) != true {
+					t.Errorf("Sampling %T gave %v, %v from its centre.", test.shape, p, d.Magnitude())
+				}
+			} else if p.Y != 0 || p.X < 0 || p.Z < 0 || p.X/3+p.Z/2 > 1 {
+				t.Errorf("Sampling the triangle gave %v, which is outside it.", p)
+			}
+		}
+	}
+}
@@ -18,6 +18,20 @@ type Shape interface {
 	Transform() matrix.Matrix
 	SetTransform(m matrix.Matrix)
 	InverseTransform() matrix.Matrix
+	EndTransform() matrix.Matrix
+	SetEndTransform(m matrix.Matrix)
+	TransformAt(time float64) matrix.Matrix
+	InverseTransformAt(time float64) matrix.Matrix
+	Tangent() (vector.Vector, bool)
+	SetTangent(t vector.Vector)
+	CastsShadows() bool
+	SetCastsShadows(casts bool)
+	ReceivesShadows() bool
+	SetReceivesShadows(receives bool)
+	VisibleToCamera() bool
+	SetVisibleToCamera(visible bool)
+	VisibleInReflections() bool
+	SetVisibleInReflections(visible bool)
 	LocalIntersect(r ray.Ray) Intersections
 	LocalNormalAt(p vector.Vector) vector.Vector
 	SavedRay() ray.Ray
@@ -26,10 +40,20 @@ type Shape interface {
 
 // Sphere is the struct for spheres
 type shape struct {
-	id        int
-	material  material.Material
-	transform matrix.Matrix
-	savedRay  ray.Ray
+	id           int
+	material     material.Material
+	transform    matrix.Matrix
+	endTransform matrix.Matrix
+	moving       bool
+	// motion holds the decomposed transforms of a moving shape.
+	motion       matrix.Motion
+	tangent      vector.Vector
+	hasTangent   bool
+	castsShadows bool
+	// receivesShadows, visibleToCamera and visibleInReflections are true for
+	// ordinary shapes.
+	receivesShadows, visibleToCamera, visibleInReflections bool
+	savedRay                                               ray.Ray
 }
 
 // ID returns the ID of the object
@@ -55,6 +79,9 @@ func (s shape) Transform() matrix.Matrix {
 // SetTransform sets the transform matrix for the shape.
 func (s *shape) SetTransform(m matrix.Matrix) {
 	s.transform = m
+	if s.moving {
+		s.motion = matrix.NewMotion(s.transform, s.endTransform)
+	}
 }
 
 // InverseTransform returns the inverse of the shapes transform matrix.
@@ -63,6 +90,93 @@ func (s shape) InverseTransform() matrix.Matrix {
 	return t
 }
 
+// EndTransform returns the shape's transform matrix at the end of its motion.
+// For a shape which does not move it is the same as Transform.
+func (s shape) EndTransform() matrix.Matrix {
+	if !s.moving {
+		return s.transform
+	}
+	return s.endTransform
+}
+
+// SetEndTransform sets the transform matrix for the shape at the end of its
+// motion, which starts at its transform.
+func (s *shape) SetEndTransform(m matrix.Matrix) {
+	s.endTransform = m
+	s.moving = true
+	s.motion = matrix.NewMotion(s.transform, s.endTransform)
+}
+
+// TransformAt returns the shape's transform matrix at time, interpolated from
+// its transform at time 0 to its end transform at time 1.
+func (s shape) TransformAt(time float64) matrix.Matrix {
+	if !s.moving || time == 0 {
+		return s.transform
+	}
+	return s.motion.At(time)
+}
+
+// InverseTransformAt returns the inverse of the shape's transform matrix at time.
+func (s shape) InverseTransformAt(time float64) matrix.Matrix {
+	t, _ := s.TransformAt(time).Invert()
+	return t
+}
+
+// Tangent returns the tangent set on the shape in local space, and whether one
+// has been set.
+func (s shape) Tangent() (vector.Vector, bool) {
+	return s.tangent, s.hasTangent
+}
+
+// SetTangent sets the tangent of the shape in local space, replacing the one
+// from its UV mapping.
+func (s *shape) SetTangent(t vector.Vector) {
+	s.tangent = t
+	s.hasTangent = true
+}
+
+// CastsShadows returns true if the shape blocks light from reaching others.
+func (s shape) CastsShadows() bool {
+	return s.castsShadows
+}
+
+// SetCastsShadows sets whether the shape blocks light from reaching others.
+func (s *shape) SetCastsShadows(casts bool) {
+	s.castsShadows = casts
+}
+
+// ReceivesShadows returns true if other shapes can block light from the shape.
+func (s shape) ReceivesShadows() bool {
+	return s.receivesShadows
+}
+
+// SetReceivesShadows sets whether other shapes can block light from the shape.
+func (s *shape) SetReceivesShadows(receives bool) {
+	s.receivesShadows = receives
+}
+
+// VisibleToCamera returns true if the shape can be seen directly by the camera.
+func (s shape) VisibleToCamera() bool {
+	return s.visibleToCamera
+}
+
+// SetVisibleToCamera sets whether the shape can be seen directly by the camera.
+func (s *shape) SetVisibleToCamera(visible bool) {
+	s.visibleToCamera = visible
+}
+
+// VisibleInReflections returns true if the shape can be seen in reflections and
+// refractions.
+func (s shape) VisibleInReflections() bool {
+	return s.visibleInReflections
+}
+
+// SetVisibleInReflections sets whether the shape can be seen in reflections and
+// refractions.
+func (s *shape) SetVisibleInReflections(visible bool) {
+	s.visibleInReflections = visible
+}
+
 func (s *shape) LocalIntersect(r ray.Ray) Intersections {
 	s.SaveRay(r)
 	return Intersections{}
@@ -86,6 +200,7 @@ func (s *shape) LocalNormalAt(p vector.Vector) vector.Vector {
 func newShape() shape {
 	return shape{
 		id: getID(), material: material.New(), transform: matrix.IdentityMatrix(4),
+		castsShadows: true, receivesShadows: true, visibleToCamera: true, visibleInReflections: true,
 	}
 }
 
@@ -145,18 +260,68 @@ func NewPlane() Shape {
 	return &Plane{newShape()}
 }
 
-// Intersect returns a list of intersectioins between ray and the shape.
+// Triangle is the type for triangles.
+type Triangle struct {
+	shape
+	P1, P2, P3, E1, E2, Normal vector.Vector
+}
+
+// LocalNormalAt returns the normal vector of the triangle at the given point in local space.
+func (s Triangle) LocalNormalAt(p vector.Vector) vector.Vector {
+	return s.Normal
+}
+
+// LocalIntersect returns a list of intersectioins between ray and the shape in local space.
+func (s Triangle) LocalIntersect(r ray.Ray) Intersections {
+	dirCrossE2 := vector.CrossProduct(r.Direction, s.E2)
+	det := vector.DotProduct(s.E1, dirCrossE2)
+	if math.Abs(det) < comparison.EPSLION {
+		return Intersections{}
+	}
+	f := 1 / det
+	p1ToOrigin := vector.Subtract(r.Origin, s.P1)
+	u := f * vector.DotProduct(p1ToOrigin, dirCrossE2)
+	if u < 0 || u > 1 {
+		return Intersections{}
+	}
+	originCrossE1 := vector.CrossProduct(p1ToOrigin, s.E1)
+	v := f * vector.DotProduct(r.Direction, originCrossE1)
+	if v < 0 || u+v > 1 {
+		return Intersections{}
+	}
+	t := f * vector.DotProduct(s.E2, originCrossE1)
+	return NewIntersections(NewIntersection(t, &s))
+}
+
+// NewTriangle returns a new Triangle shape with the given corners.
+func NewTriangle(p1, p2, p3 vector.Vector) Shape {
+	e1 := vector.Subtract(p2, p1)
+	e2 := vector.Subtract(p3, p1)
+	normal := vector.CrossProduct(e2, e1)
+	return &Triangle{
+		shape: newShape(), P1: p1, P2: p2, P3: p3, E1: e1, E2: e2, Normal: normal.Normalize(),
+	}
+}
+
+// Intersect returns a list of intersectioins between ray and the shape, placed
+// where it is at the time of the ray.
 func Intersect(s Shape, r ray.Ray) Intersections {
-	localRay := r.Transform(s.InverseTransform())
+	localRay := r.Transform(s.InverseTransformAt(r.Time))
 	return s.LocalIntersect(localRay)
 }
 
 // NormalAt returns the normal vector of a shape at the given point.
 func NormalAt(s Shape, p vector.Vector) vector.Vector {
-	localPoint := vector.MultiplyMatrixByVector(s.InverseTransform(), p)
+	return NormalAtTime(s, p, 0)
+}
+
+// NormalAtTime returns the normal vector of a shape at the given point, with the
+// shape placed where it is at time.
+func NormalAtTime(s Shape, p vector.Vector, time float64) vector.Vector {
+	inverse := s.InverseTransformAt(time)
+	localPoint := vector.MultiplyMatrixByVector(inverse, p)
 	localNormal := s.LocalNormalAt(localPoint)
-	worldNormal := vector.MultiplyMatrixByVector(
-		s.InverseTransform().Transpose(), localNormal)
+	worldNormal := vector.MultiplyMatrixByVector(inverse.Transpose(), localNormal)
 	worldNormal.W = 0
 	return worldNormal.Normalize()
 }
@@ -40,6 +40,34 @@ func TestShapeSetMaterial(t *testing.T) {
 	}
 }
 
+// flagTests are the flags of a shape, with their getters and setters.
+var flagTests = []struct {
+	name string
+	get  func(Shape) bool
+	set  func(Shape, bool)
+}{
+	{name: "CastsShadows", get: Shape.CastsShadows, set: Shape.SetCastsShadows},
+	{name: "ReceivesShadows", get: Shape.ReceivesShadows, set: Shape.SetReceivesShadows},
+	{name: "VisibleToCamera", get: Shape.VisibleToCamera, set: Shape.SetVisibleToCamera},
+	{
+		name: "VisibleInReflections", get: Shape.VisibleInReflections,
+		set: Shape.SetVisibleInReflections,
+	},
+}
+
+func TestShapeFlags(t *testing.T) {
+	for _, test := range flagTests {
+		s := newShape()
+		if test.get(&s) != true {
+			t.Errorf("Shape %s was not true by default.", test.name)
+		}
+		test.set(&s, false)
+		if test.get(&s) != false {
+			t.Errorf("Could not set shape %s to false.", test.name)
+		}
+	}
+}
+
 func TestShapeSetTransform(t *testing.T) {
 	s := newShape()
 	transform := matrix.TranslationMatrix(2, 3, 4)
@@ -267,3 +295,146 @@ func TestPlaneLocalIntersect(t *testing.T) {
 		}
 	}
 }
+
+func TestShapeEndTransform(t *testing.T) {
+	s := newShape()
+	s.SetTransform(matrix.TranslationMatrix(1, 0, 0))
+	if matrix.Equal(s.EndTransform(), s.Transform()) != true {
+		t.Error("Static shape end transform was not its transform.")
+	}
+	s.SetEndTransform(matrix.TranslationMatrix(3, 0, 0))
+	var tests = []struct {
+		time     float64
+		expected matrix.Matrix
+	}{
+		{time: 0, expected: matrix.TranslationMatrix(1, 0, 0)},
+		{time: 0.5, expected: matrix.TranslationMatrix(2, 0, 0)},
+		{time: 1, expected: matrix.TranslationMatrix(3, 0, 0)},
+	}
+	for _, test := range tests {
+		result := s.TransformAt(test.time)
+		if matrix.Equal(result, test.expected) != true {
+			t.Errorf("Shape transform at %v was %+v, expected %+v.", test.time, result, test.expected)
+		}
+		inverse, _ := test.expected.Invert()
+		if matrix.Equal(s.InverseTransformAt(test.time), inverse) != true {
+			t.Errorf("Shape inverse transform at %v was incorrect.", test.time)
+		}
+	}
+}
+
+func TestShapeTransformAfterEndTransform(t *testing.T) {
+	s := newShape()
+	s.SetEndTransform(matrix.TranslationMatrix(3, 0, 0))
+	s.SetTransform(matrix.TranslationMatrix(1, 0, 0))
+	expected := matrix.TranslationMatrix(2, 0, 0)
+	if result := s.TransformAt(0.5); matrix.Equal(result, expected) != true {
+		t.Errorf("Shape transform at 0.5 was %+v, expected %+v.", result, expected)
+	}
+}
+
+func TestIntersectMovingShape(t *testing.T) {
+	var tests = []struct {
+		origin   vector.Vector
+		time     float64
+		expected []float64
+	}{
+		{origin: vector.NewPoint(0, 0, -5), time: 0, expected: []float64{4, 6}},
+		{origin: vector.NewPoint(0, 0, -5), time: 0.5, expected: []float64{}},
+		{origin: vector.NewPoint(0, 3, -5), time: 0.5, expected: []float64{4, 6}},
+		{origin: vector.NewPoint(0, 6, -5), time: 1, expected: []float64{4, 6}},
+	}
+	s := NewSphere()
+	s.SetEndTransform(matrix.TranslationMatrix(0, 6, 0))
+	for _, test := range tests {
+		r := ray.NewAtTime(test.origin, vector.NewVector(0, 0, 1), test.time)
+		xs := Intersect(s, r)
+		if comparison.EqualSlice(xs.TSlice(), test.expected) != true {
+			t.Errorf(
+				"Intersecting moving sphere with %+v gave %v, expected %v.",
+				r, xs.TSlice(), test.expected,
+			)
+		}
+	}
+}
+
+func TestNormalAtTime(t *testing.T) {
+	s := NewSphere()
+	s.SetEndTransform(matrix.TranslationMatrix(0, 2, 0))
+	var tests = []struct {
+		point    vector.Vector
+		time     float64
+		expected vector.Vector
+	}{
+		{point: vector.NewPoint(0, 1, 0), time: 0, expected: vector.NewVector(0, 1, 0)},
+		{point: vector.NewPoint(-1, 1, 0), time: 0.5, expected: vector.NewVector(-1, 0, 0)},
+		{point: vector.NewPoint(1, 2, 0), time: 1, expected: vector.NewVector(1, 0, 0)},
+	}
+	for _, test := range tests {
+		result := NormalAtTime(s, test.point, test.time)
+		if vector.Equal(result, test.expected) != true {
+			t.Errorf(
+				"Normal of moving sphere at %+v at time %v was %+v, expected %+v.",
+				test.point, test.time, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestNewTriangle(t *testing.T) {
+	s := NewTriangle(vector.NewPoint(0, 1, 0), vector.NewPoint(-1, 0, 0), vector.NewPoint(1, 0, 0))
+	tri := s.(*Triangle)
+	if !vector.Equal(tri.E1, vector.NewVector(-1, -1, 0)) ||
+		!vector.Equal(tri.E2, vector.NewVector(1, -1, 0)) ||
+		!vector.Equal(tri.Normal, vector.NewVector(0, 0, -1)) {
+		t.Errorf("Triangle was %+v.", tri)
+	}
+	for _, p := range []vector.Vector{vector.NewPoint(0, 0.5, 0), vector.NewPoint(-0.5, 0.75, 0)} {
+		if !vector.Equal(s.LocalNormalAt(p), tri.Normal) {
+			t.Errorf("Triangle normal at %v was %v, expected %v.", p, s.LocalNormalAt(p), tri.Normal)
+		}
+	}
+}
+
+func TestTriangleLocalIntersect(t *testing.T) {
+	var tests = []struct {
+		ray      ray.Ray
+		expected []float64
+	}{
+		{
+			// A ray parallel to the triangle.
+			ray:      ray.New(vector.NewPoint(0, -1, -2), vector.NewVector(0, 1, 0)),
+			expected: []float64{},
+		},
+		{
+			// A ray missing the p1-p3 edge.
+			ray:      ray.New(vector.NewPoint(1, 1, -2), vector.NewVector(0, 0, 1)),
+			expected: []float64{},
+		},
+		{
+			// A ray missing the p1-p2 edge.
+			ray:      ray.New(vector.NewPoint(-1, 1, -2), vector.NewVector(0, 0, 1)),
+			expected: []float64{},
+		},
+		{
+			// A ray missing the p2-p3 edge.
+			ray:      ray.New(vector.NewPoint(0, -1, -2), vector.NewVector(0, 0, 1)),
+			expected: []float64{},
+		},
+		{
+			ray:      ray.New(vector.NewPoint(0, 0.5, -2), vector.NewVector(0, 0, 1)),
+			expected: []float64{2},
+		},
+	}
+	s := NewTriangle(vector.NewPoint(0, 1, 0), vector.NewPoint(-1, 0, 0), vector.NewPoint(1, 0, 0))
+	for _, test := range tests {
+		intersections := s.LocalIntersect(test.ray)
+		result := intersections.TSlice()
+		if !comparison.EqualSlice(result, test.expected) {
+			t.Errorf(
+				"Intersection of triangle and ray (%v) was %v, expected %v",
+				test.ray, result, test.expected,
+			)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package shape
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// UVMapper is implemented by shapes with a mapping of their surface to (u, v)
+// texture co-ordinates.
+type UVMapper interface {
+	// LocalUV returns the texture co-ordinates of a point in local space.
+	LocalUV(p vector.Vector) (float64, float64)
+	// LocalTangent returns the direction in local space in which u increases
+	// at a point.
+	LocalTangent(p vector.Vector) vector.Vector
+	// LocalBitangent returns the direction in local space in which v
+	// increases at a point.
+	LocalBitangent(p vector.Vector) vector.Vector
+}
+
+// LocalUV returns the spherical mapping of a point, with u around the sphere
+// and v from the bottom to the top.
+func (s Sphere) LocalUV(p vector.Vector) (float64, float64) {
+	theta := math.Atan2(p.X, p.Z)
+	radius := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+	phi := math.Acos(p.Y / radius)
+	return 1 - (theta/(2*math.Pi) + 0.5), 1 - phi/math.Pi
+}
+
+// LocalTangent returns the direction around the sphere in which u increases.
+func (s Sphere) LocalTangent(p vector.Vector) vector.Vector {
+	return vector.NewVector(-p.Z, 0, p.X)
+}
+
+// LocalBitangent returns the direction up the sphere in which v increases.
+func (s Sphere) LocalBitangent(p vector.Vector) vector.Vector {
+	return vector.NewVector(-p.X*p.Y, p.X*p.X+p.Z*p.Z, -p.Y*p.Z)
+}
+
+// LocalUV returns the planar mapping of a point, repeating every unit in x and z.
+func (s Plane) LocalUV(p vector.Vector) (float64, float64) {
+	return p.X - math.Floor(p.X), p.Z - math.Floor(p.Z)
+}
+
+// LocalTangent returns the x axis.
+func (s Plane) LocalTangent(p vector.Vector) vector.Vector {
+	return vector.NewVector(1, 0, 0)
+}
+
+// LocalBitangent returns the z axis.
+func (s Plane) LocalBitangent(p vector.Vector) vector.Vector {
+	return vector.NewVector(0, 0, 1)
+}
+
+// LocalUV returns the barycentric co-ordinates of a point along E1 and E2.
+func (s Triangle) LocalUV(p vector.Vector) (float64, float64) {
+	d := vector.Subtract(p, s.P1)
+	d11, d12 := vector.DotProduct(s.E1, s.E1), vector.DotProduct(s.E1, s.E2)
+	d22 := vector.DotProduct(s.E2, s.E2)
+	d1, d2 := vector.DotProduct(d, s.E1), vector.DotProduct(d, s.E2)
+	det := d11*d22 - d12*d12
+	return (d22*d1 - d12*d2) / det, (d11*d2 - d12*d1) / det
+}
+
+// LocalTangent returns the direction of E1.
+func (s Triangle) LocalTangent(p vector.Vector) vector.Vector {
+	return s.E1.Normalize()
+}
+
+// LocalBitangent returns the direction of E2.
+func (s Triangle) LocalBitangent(p vector.Vector) vector.Vector {
+	return s.E2.Normalize()
+}
+
+// UVAtTime returns the texture co-ordinates of a point on a shape placed where
+// it is at time. ok is false if the shape has no UV mapping.
+func UVAtTime(s Shape, p vector.Vector, time float64) (u, v float64, ok bool) {
+	mapper, ok := s.(UVMapper)
+	if !ok {
+		return 0, 0, false
+	}
+	u, v = mapper.LocalUV(vector.MultiplyMatrixByVector(s.InverseTransformAt(time), p))
+	return u, v, true
+}
+
+// TangentAtTime returns the unit tangent of a shape at a point, with the shape
+// placed where it is at time. It is the shape's own tangent if it has been set,
+// otherwise the direction of increasing u, made perpendicular to the normal.
+// Shapes with neither have an arbitrary tangent.
+func TangentAtTime(s Shape, p vector.Vector, time float64) vector.Vector {
+	normal := NormalAtTime(s, p, time)
+	local, ok := s.Tangent()
+	if !ok {
+		if mapper, isMapped := s.(UVMapper); isMapped {
+			local = mapper.LocalTangent(vector.MultiplyMatrixByVector(s.InverseTransformAt(time), p))
+			ok = true
+		}
+	}
+	if ok {
+		local.W = 0
+		t := vector.MultiplyMatrixByVector(s.TransformAt(time), local)
+		t = vector.Subtract(t, normal.ScalarMultiply(vector.DotProduct(t, normal)))
+		if t.Magnitude() > comparison.EPSLION {
+			return t.Normalize()
+		}
+	}
+	t, _ := vector.Basis(normal)
+	return t
+}
+
+// ShadingNormalAtTime returns the normal n of a shape at a point, perturbed by
+// the bump map of its material about the tangent t, with the shape placed where
+// it is at time. Shapes without a bump map or texture co-ordinates keep n.
+func ShadingNormalAtTime(s Shape, p, n, t vector.Vector, time float64) vector.Vector {
+	bump := s.Material().Bump
+	if bump == nil {
+		return n
+	}
+	u, v, ok := UVAtTime(s, p, time)
+	if !ok {
+		return n
+	}
+	return bump.Perturb(u, v, n, t, bitangentAtTime(s, p, n, t, time))
+}
+
+// bitangentAtTime returns the unit direction of increasing v on a shape at a
+// point with the normal n, made perpendicular to n, with the shape placed where
+// it is at time. Where the shape's mapping gives none it is the cross product
+// of the tangent t and n.
+func bitangentAtTime(s Shape, p, n, t vector.Vector, time float64) vector.Vector {
+	if mapper, ok := s.(UVMapper); ok {
+		local := mapper.LocalBitangent(vector.MultiplyMatrixByVector(s.InverseTransformAt(time), p))
+		local.W = 0
+		b := vector.MultiplyMatrixByVector(s.TransformAt(time), local)
+		b = vector.Subtract(b, n.ScalarMultiply(vector.DotProduct(b, n)))
+		if b.Magnitude() > comparison.EPSLION {
+			return b.Normalize()
+		}
+	}
+	return vector.CrossProduct(t, n)
+}
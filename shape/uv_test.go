@@ -0,0 +1,161 @@
+package shape
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/bump"
+	"github.com/lukeshiner/raytrace/canvas"
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/material"
+	"github.com/lukeshiner/raytrace/matrix"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestLocalUV(t *testing.T) {
+	unmapped := newShape()
+	triangle := NewTriangle(vector.NewPoint(0, 0, 0), vector.NewPoint(2, 0, 0), vector.NewPoint(0, 0, 4))
+	var tests = []struct {
+		shape  Shape
+		point  vector.Vector
+		u, v   float64
+		mapped bool
+	}{
+		{shape: NewSphere(), point: vector.NewPoint(0, 0, -1), u: 0, v: 0.5, mapped: true},
+		{shape: NewSphere(), point: vector.NewPoint(1, 0, 0), u: 0.25, v: 0.5, mapped: true},
+		{shape: NewSphere(), point: vector.NewPoint(0, 0, 1), u: 0.5, v: 0.5, mapped: true},
+		{shape: NewSphere(), point: vector.NewPoint(0, 1, 0), u: 0.5, v: 1, mapped: true},
+		{
+			shape: NewSphere(), point: vector.NewPoint(math.Sqrt2/2, math.Sqrt2/2, 0),
+			u: 0.25, v: 0.75, mapped: true,
+		},
+		{shape: NewPlane(), point: vector.NewPoint(0.25, 0, 0.5), u: 0.25, v: 0.5, mapped: true},
+		{shape: NewPlane(), point: vector.NewPoint(-0.25, 0, -1.75), u: 0.75, v: 0.25, mapped: true},
+		{shape: triangle, point: vector.NewPoint(1, 0, 1), u: 0.5, v: 0.25, mapped: true},
+		{shape: &unmapped, point: vector.NewPoint(0, 0, 0), mapped: false},
+	}
+	for _, test := range tests {
+		u, v, ok := UVAtTime(test.shape, test.point, 0)
+		if ok != test.mapped || comparison.EpsilonEqual(u, test.u) != true ||
+			comparison.EpsilonEqual(v, test.v) != true {
+			t.Errorf(
+				"UV of %T at %v was (%v, %v, %v), expected (%v, %v, %v).",
+				test.shape, test.point, u, v, ok, test.u, test.v, test.mapped,
+			)
+		}
+	}
+}
+
+func TestTangentAtTime(t *testing.T) {
+	rotated := NewPlane()
+	rotated.SetTransform(matrix.RotationYMatrix(math.Pi / 2))
+	custom := NewPlane()
+	custom.SetTangent(vector.NewVector(1, 0, 1))
+	tilted := NewPlane()
+	tilted.SetTangent(vector.NewVector(0, 1, 1))
+	var tests = []struct {
+		shape    Shape
+		point    vector.Vector
+		expected vector.Vector
+	}{
+		{shape: NewSphere(), point: vector.NewPoint(0, 0, -1), expected: vector.NewVector(1, 0, 0)},
+		{shape: NewSphere(), point: vector.NewPoint(1, 0, 0), expected: vector.NewVector(0, 0, 1)},
+		{shape: NewPlane(), point: vector.NewPoint(3, 0, 2), expected: vector.NewVector(1, 0, 0)},
+		{shape: rotated, point: vector.NewPoint(3, 0, 2), expected: vector.NewVector(0, 0, -1)},
+		{
+			shape: custom, point: vector.NewPoint(0, 0, 0),
+			expected: vector.NewVector(math.Sqrt2/2, 0, math.Sqrt2/2),
+		},
+		{
+			// Tangents are made perpendicular to the normal.
+			shape: tilted, point: vector.NewPoint(0, 0, 0), expected: vector.NewVector(0, 0, 1),
+		},
+	}
+	for _, test := range tests {
+		result := TangentAtTime(test.shape, test.point, 0)
+		if !vector.Equal(result, test.expected) {
+			t.Errorf("Tangent of %T at %v was %v, expected %v.", test.shape, test.point, result, test.expected)
+		}
+	}
+	// At the poles of a sphere any tangent perpendicular to the normal will do.
+	pole := TangentAtTime(NewSphere(), vector.NewPoint(0, 1, 0), 0)
+	if comparison.EpsilonEqual(pole.Magnitude(), 1) != true || comparison.EpsilonEqual(pole.Y, 0) != true {
+		t.Errorf("Tangent at the pole was %v.", pole)
+	}
+}
+
+func TestBitangentAtTime(t *testing.T) {
+	mirrored := NewTriangle(vector.NewPoint(0, 0, 0), vector.NewPoint(1, 0, 0), vector.NewPoint(0, 0, 1))
+	mirrored.SetTransform(matrix.ScalingMatrix(-1, 1, 1))
+	skewed := NewTriangle(vector.NewPoint(0, 0, 0), vector.NewPoint(1, 0, 0), vector.NewPoint(1, 0, 1))
+	var tests = []struct {
+		shape           Shape
+		point           vector.Vector
+		normal, tangent vector.Vector
+		expected        vector.Vector
+	}{
+		{
+			shape: NewSphere(), point: vector.NewPoint(0, 0, -1),
+			normal: vector.NewVector(0, 0, -1), tangent: vector.NewVector(1, 0, 0),
+			expected: vector.NewVector(0, 1, 0),
+		},
+		{
+			shape: NewPlane(), point: vector.NewPoint(3, 0, 2),
+			normal: vector.NewVector(0, 1, 0), tangent: vector.NewVector(1, 0, 0),
+			expected: vector.NewVector(0, 0, 1),
+		},
+		{
+			// Mirroring the triangle turns its tangent but not the direction
+			// of increasing v.
+			shape: mirrored, point: vector.NewPoint(-0.25, 0, 0.25),
+			normal: vector.NewVector(0, 1, 0), tangent: vector.NewVector(-1, 0, 0),
+			expected: vector.NewVector(0, 0, 1),
+		},
+		{
+			// v increases along E2, which is not perpendicular to E1.
+			shape: skewed, point: vector.NewPoint(0.5, 0, 0.25),
+			normal: vector.NewVector(0, 1, 0), tangent: vector.NewVector(1, 0, 0),
+			expected: vector.NewVector(math.Sqrt2/2, 0, math.Sqrt2/2),
+		},
+		{
+			// At the poles of a sphere v has no direction.
+			shape: NewSphere(), point: vector.NewPoint(0, 1, 0),
+			normal: vector.NewVector(0, 1, 0), tangent: vector.NewVector(1, 0, 0),
+			expected: vector.NewVector(0, 0, 1),
+		},
+	}
+	for _, test := range tests {
+		result := bitangentAtTime(test.shape, test.point, test.normal, test.tangent, 0)
+		if !vector.Equal(result, test.expected) {
+			t.Errorf("Bitangent of %T at %v was %v, expected %v.", test.shape, test.point, result, test.expected)
+		}
+	}
+}
+
+func TestShadingNormalAtTime(t *testing.T) {
+	image := canvas.New(1, 1)
+	image.WritePixel(0, 0, colour.New(1, 0.5, 1))
+	m := material.New()
+	m.Bump = bump.NewNormalMap(&image)
+	bumped := NewPlane()
+	bumped.SetMaterial(m)
+	unmapped := newShape()
+	unmapped.SetMaterial(m)
+	n, tangent := vector.NewVector(0, 1, 0), vector.NewVector(1, 0, 0)
+	var tests = []struct {
+		shape    Shape
+		expected vector.Vector
+	}{
+		{shape: NewPlane(), expected: n},
+		{shape: bumped, expected: vector.NewVector(math.Sqrt2/2, math.Sqrt2/2, 0)},
+		// Shapes without texture co-ordinates can't be bumped.
+		{shape: &unmapped, expected: n},
+	}
+	for _, test := range tests {
+		result := ShadingNormalAtTime(test.shape, vector.NewPoint(0.5, 0, 0.5), n, tangent, 0)
+		if !vector.Equal(result, test.expected) {
+			t.Errorf("Shading normal of %T was %v, expected %v.", test.shape, result, test.expected)
+		}
+	}
+}
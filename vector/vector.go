@@ -186,3 +186,18 @@ func MultiplyMatrixByVector(m matrix.Matrix, v Vector) Vector {
 	tuple := matrix.MultiplyTuple(m, v.AsSlice())
 	return FromSlice(tuple)
 }
+
+// Basis returns two unit vectors which, together with the unit vector n, form an
+// orthonormal basis.
+func Basis(n Vector) (Vector, Vector) {
+	var a Vector
+	if math.Abs(n.X) > 0.9 {
+		a = NewVector(0, 1, 0)
+	} else {
+		a = NewVector(1, 0, 0)
+	}
+	t := CrossProduct(a, n)
+	t = t.Normalize()
+	b := CrossProduct(n, t)
+	return t, b
+}
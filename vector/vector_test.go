@@ -675,3 +675,22 @@ func TestShear(t *testing.T) {
 		}
 	}
 }
+
+func TestBasis(t *testing.T) {
+	var tests = []Vector{
+		NewVector(0, 1, 0),
+		NewVector(1, 0, 0),
+		NewVector(0, 0, -1),
+		NewVector(0.26726, 0.53452, 0.80178),
+	}
+	for _, n := range tests {
+		a, b := Basis(n)
+		if comparison.EpsilonEqual(a.Magnitude(), 1) != true ||
+			comparison.EpsilonEqual(b.Magnitude(), 1) != true ||
+			comparison.EpsilonEqual(DotProduct(a, n), 0) != true ||
+			comparison.EpsilonEqual(DotProduct(b, n), 0) != true ||
+			comparison.EpsilonEqual(DotProduct(a, b), 0) != true {
+			t.Errorf("Basis(%+v) returned %+v, %+v, which is not orthonormal.", n, a, b)
+		}
+	}
+}
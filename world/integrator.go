@@ -0,0 +1,156 @@
+package world
+
+import (
+	"math"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/sampling"
+	"github.com/lukeshiner/raytrace/shape"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// Integrator is the interface for ways of finding the light arriving along a ray.
+type Integrator interface {
+	Radiance(w World, r ray.Ray) colour.Colour
+}
+
+// Whitted shades hits with ShadeHit.
+type Whitted struct{}
+
+// Radiance returns the colour for a ray in a world.
+func (Whitted) Radiance(w World, r ray.Ray) colour.Colour {
+	return ColourAt(w, r)
+}
+
+// PathTracer follows random bounces from each hit, chosen by its material,
+// adding the light reaching every bounce directly from the world's lights and
+// emissive objects.
+type PathTracer struct {
+	// MaxDepth is the most bounces a path takes.
+	MaxDepth int
+	// After RouletteDepth bounces paths are ended at random, with a chance
+	// which falls as less light is carried.
+	RouletteDepth int
+}
+
+// Radiance returns an unbiased estimate of the light arriving along a ray.
+func (p PathTracer) Radiance(w World, r ray.Ray) colour.Colour {
+	radiance := colour.New(0, 0, 0)
+	throughput := colour.New(1, 1, 1)
+	specular := false
+	for depth := 0; depth <= p.MaxDepth; depth++ {
+		intersections := visibleIntersections(w, r, depth == 0)
+		hit, err := intersections.Hit()
+		if err != nil {
+			return radiance.Add(throughput.Mult(background(w, r)))
+		}
+		comps := prepareComputations(hit, r, intersections)
+		throughput = throughput.Mult(Absorption(comps, r))
+		m := comps.Object.Material()
+		// Light from emitters which can be sampled is gathered at the bounce
+		// before, unless that bounce was specular.
+		if _, sampled := comps.Object.(shape.AreaSampler); depth == 0 || specular || !sampled {
+			radiance = radiance.Add(throughput.Mult(m.Emission))
+		}
+		surface := comps.Surface()
+		reflectance := func(l vector.Vector) colour.Colour {
+			return surface.Eval(l, comps.EyeV, comps.Normal()).ScalarMult(math.Pi)
+		}
+		radiance = radiance.Add(throughput.Mult(directLighting(w, comps, reflectance)))
+		radiance = radiance.Add(throughput.Mult(emissionLighting(w, comps, reflectance)))
+		s := surface.Sample(
+			comps.EyeV, comps.Normal(), sampling.Float64(), sampling.Float64(), sampling.Float64())
+		if s.PDF <= 0 {
+			break
+		}
+		throughput = throughput.Mult(s.Weight)
+		specular = s.Specular
+		if depth >= p.RouletteDepth {
+			survival := math.Min(0.95, math.Max(throughput.Red, math.Max(throughput.Green, throughput.Blue)))
+			if sampling.Float64() >= survival {
+				break
+			}
+			throughput = throughput.ScalarMult(1 / survival)
+		}
+		r = ray.NewAtTime(comps.Origin(s.Direction), s.Direction, comps.Time)
+	}
+	return radiance
+}
+
+// NewPathTracer returns a path tracer which takes up to eight bounces, with
+// Russian roulette after three.
+func NewPathTracer() PathTracer {
+	return PathTracer{MaxDepth: 8, RouletteDepth: 3}
+}
+
+// DirectLighting returns the light from the world's lights falling on a
+// computed intersection, scaled by the cosine of its angle to the normal. It is
+// the light which a white surface with a Diffuse of one reflects in ShadeHit.
+func DirectLighting(w World, comps Comps) colour.Colour {
+	return directLighting(w, comps, white)
+}
+
+func directLighting(w World, comps Comps, reflectance func(vector.Vector) colour.Colour) colour.Colour {
+	c := colour.New(0, 0, 0)
+	for _, l := range w.Lights {
+		direction, _ := l.DirectionFrom(comps.Point)
+		cosTheta := vector.DotProduct(direction, comps.NormalV)
+		if cosTheta <= 0 {
+			continue
+		}
+		transmittance := lightReaching(w, comps, l)
+		c = c.Add(l.Intensity().Mult(transmittance).Mult(reflectance(direction)).ScalarMult(cosTheta))
+	}
+	return c
+}
+
+// EmissionLighting returns the light from the world's emissive objects falling
+// on a computed intersection, divided by pi so a white surface with a Diffuse of
+// one reflects it, estimated with a sample on each object.
+func EmissionLighting(w World, comps Comps) colour.Colour {
+	return emissionLighting(w, comps, white)
+}
+
+func emissionLighting(w World, comps Comps, reflectance func(vector.Vector) colour.Colour) colour.Colour {
+	c := colour.New(0, 0, 0)
+	for _, object := range w.Objects {
+		m := object.Material()
+		if !m.Emissive() || object.ID() == comps.Object.ID() {
+			continue
+		}
+		p, n, pdf, ok := shape.SampleSurface(
+			object, sampling.Float64(), sampling.Float64(), comps.Time)
+		if !ok || pdf <= 0 {
+			continue
+		}
+		toLight := vector.Subtract(p, comps.OverPoint)
+		distance := toLight.Magnitude()
+		direction := toLight.ScalarDivide(distance)
+		cosTheta := vector.DotProduct(direction, comps.NormalV)
+		cosLight := math.Abs(vector.DotProduct(direction, n))
+		if cosTheta <= 0 || cosLight <= 0 {
+			continue
+		}
+		h, err := shadowHit(w, ray.NewAtTime(comps.OverPoint, direction, comps.Time))
+		if err == nil && h.T < distance-comparison.EPSLION && comps.Object.ReceivesShadows() {
+			continue
+		}
+		light := m.Emission.Mult(reflectance(direction))
+		c = c.Add(light.ScalarMult(cosTheta * cosLight / (math.Pi * distance * distance * pdf)))
+	}
+	return c
+}
+
+func white(vector.Vector) colour.Colour {
+	return colour.New(1, 1, 1)
+}
+
+// background returns the colour of the world's background along a ray.
+func background(w World, r ray.Ray) colour.Colour {
+	if w.Background == nil {
+		return colour.New(0, 0, 0)
+	}
+	return w.Background.ColourAt(r.Direction.Normalize())
+}
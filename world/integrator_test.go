@@ -0,0 +1,237 @@
+package world
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lukeshiner/raytrace/bsdf"
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/environment"
+	"github.com/lukeshiner/raytrace/light"
+	"github.com/lukeshiner/raytrace/material"
+	"github.com/lukeshiner/raytrace/matrix"
+	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/shape"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+func TestWhittedRadiance(t *testing.T) {
+	w := Default()
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	expected := ColourAt(w, r)
+	result := Whitted{}.Radiance(w, r)
+	if result.Equal(expected) != true {
+		t.Errorf("Whitted radiance was %v, expected %v.", result, expected)
+	}
+}
+
+// diffuseSphere returns a unit sphere with no highlight.
+func diffuseSphere() shape.Shape {
+	s := shape.NewSphere()
+	m := material.New()
+	m.Specular = 0
+	s.SetMaterial(m)
+	return s
+}
+
+func TestPathTracerRadiance(t *testing.T) {
+	var tests = []struct {
+		tracer   PathTracer
+		expected colour.Colour
+	}{
+		{
+			// A convex diffuse object under a uniform sky reflects the sky
+			// times its albedo.
+			tracer:   NewPathTracer(),
+			expected: colour.New(0.9, 0.45, 0.9),
+		},
+		{
+			// With no bounces the sky is never reached.
+			tracer:   PathTracer{MaxDepth: 0},
+			expected: colour.New(0, 0, 0),
+		},
+	}
+	for _, test := range tests {
+		w := New()
+		w.Objects = []shape.Shape{diffuseSphere()}
+		w.Background = environment.NewSolid(colour.New(1, 0.5, 1))
+		r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+		result := colour.New(0, 0, 0)
+		const n = 4000
+		for i := 0; i < n; i++ {
+			result = result.Add(test.tracer.Radiance(w, r))
+		}
+		result = result.ScalarMult(1.0 / n)
+		if math.Abs(result.Red-test.expected.Red) > 0.05 ||
+			math.Abs(result.Green-test.expected.Green) > 0.05 ||
+			math.Abs(result.Blue-test.expected.Blue) > 0.05 {
+			t.Errorf("Path tracer %+v radiance was %v, expected %v.", test.tracer, result, test.expected)
+		}
+	}
+}
+
+func TestPathTracerMiss(t *testing.T) {
+	w := Default()
+	w.Background = environment.NewSolid(colour.New(0.2, 0.4, 1))
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 1, 0))
+	expected := colour.New(0.2, 0.4, 1)
+	result := NewPathTracer().Radiance(w, r)
+	if result.Equal(expected) != true {
+		t.Errorf("Path tracer radiance for a miss was %v, expected %v.", result, expected)
+	}
+}
+
+func TestDirectLighting(t *testing.T) {
+	var tests = []struct {
+		light    light.Light
+		expected colour.Colour
+	}{
+		{
+			light:    light.NewPoint(colour.New(1, 0.5, 1), vector.NewPoint(0, 0, -10)),
+			expected: colour.New(1, 0.5, 1),
+		},
+		{
+			// The light is behind the surface.
+			light:    light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, 10)),
+			expected: colour.New(0, 0, 0),
+		},
+		{
+			light:    light.NewDirectional(colour.New(1, 1, 1), vector.NewVector(0, -math.Sqrt2/2, math.Sqrt2/2)),
+			expected: colour.New(math.Sqrt2/2, math.Sqrt2/2, math.Sqrt2/2),
+		},
+	}
+	for _, test := range tests {
+		w := New()
+		w.Objects = []shape.Shape{shape.NewSphere()}
+		w.Lights = []light.Light{test.light}
+		r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+		comps := PrepareComputations(shape.NewIntersection(4, w.Objects[0]), r)
+		result := DirectLighting(w, comps)
+		if result.Equal(test.expected) != true {
+			t.Errorf("Direct lighting from %+v was %v, expected %v.", test.light, result, test.expected)
+		}
+	}
+}
+
+func TestEmissionLighting(t *testing.T) {
+	emitter := shape.NewSphere()
+	emitter.SetTransform(matrix.TranslationMatrix(0, 0, -5))
+	m := material.New()
+	m.Emission = colour.New(16, 8, 0)
+	emitter.SetMaterial(m)
+	var tests = []struct {
+		emitter  shape.Shape
+		expected colour.Colour
+	}{
+		{
+			// A sphere of radius one four units away gives 1/16 of its light.
+			emitter:  emitter,
+			expected: colour.New(1, 0.5, 0),
+		},
+		{
+			// Planes cannot be sampled as lights.
+			emitter:  shape.NewPlane(),
+			expected: colour.New(0, 0, 0),
+		},
+	}
+	for _, test := range tests {
+		w := New()
+		w.Objects = []shape.Shape{shape.NewSphere(), test.emitter}
+		r := ray.New(vector.NewPoint(0, 0, -3), vector.NewVector(0, 0, 1))
+		comps := PrepareComputations(shape.NewIntersection(2, w.Objects[0]), r)
+		result := colour.New(0, 0, 0)
+		const n = 20000
+		for i := 0; i < n; i++ {
+			result = result.Add(EmissionLighting(w, comps))
+		}
+		result = result.ScalarMult(1.0 / n)
+		if math.Abs(result.Red-test.expected.Red) > 0.05 ||
+			math.Abs(result.Green-test.expected.Green) > 0.05 ||
+			math.Abs(result.Blue-test.expected.Blue) > 0.05 {
+			t.Errorf("Emission lighting from %T was %v, expected %v.", test.emitter, result, test.expected)
+		}
+	}
+}
+
+func TestPathTracerEmission(t *testing.T) {
+	w := New()
+	s := shape.NewSphere()
+	m := material.New()
+	m.Diffuse = 0
+	m.Emission = colour.New(2, 1, 0.5)
+	s.SetMaterial(m)
+	w.Objects = []shape.Shape{s}
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	result := NewPathTracer().Radiance(w, r)
+	if result.Equal(m.Emission) != true {
+		t.Errorf("Path tracer radiance of an emitter was %v, expected %v.", result, m.Emission)
+	}
+}
+
+func TestPathTracerMetallicRoughness(t *testing.T) {
+	w := New()
+	s := shape.NewSphere()
+	s.SetMaterial(material.NewMetallicRoughness(colour.New(1, 1, 1), 1, 0.3))
+	w.Objects = []shape.Shape{s}
+	w.Background = environment.NewSolid(colour.New(1, 1, 1))
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	result := 0.0
+	const n = 4000
+	for i := 0; i < n; i++ {
+		result += NewPathTracer().Radiance(w, r).Red
+	}
+	result /= n
+	// A white metal loses only a little light to masking in a uniform sky.
+	if result < 0.85 || result > 1.02 {
+		t.Errorf("Path traced white metal under a white sky was %v, expected nearly 1.", result)
+	}
+}
+
+func TestPathTracerSpecular(t *testing.T) {
+	w := New()
+	w.Background = environment.NewSolid(colour.New(0.2, 0.4, 1))
+	s := shape.NewSphere()
+	m := material.New()
+	m.BSDF = bsdf.Dielectric{IOR: 1, Tint: colour.New(1, 0.5, 0)}
+	s.SetMaterial(m)
+	w.Objects = []shape.Shape{s}
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	result := NewPathTracer().Radiance(w, r)
+	expected := colour.New(0.2, 0.1, 0)
+	if result.Equal(expected) != true {
+		t.Errorf("Path traced radiance through tinted glass was %v, expected %v.", result, expected)
+	}
+}
+
+func TestPathTracerAbsorption(t *testing.T) {
+	w := New()
+	w.Background = environment.NewSolid(colour.New(0.2, 0.4, 1))
+	s := shape.NewSphere()
+	m := material.New()
+	m.BSDF = bsdf.NewDielectric(1)
+	m.Absorption, m.Density = colour.New(0.5, 1, 0.8), 1
+	s.SetMaterial(m)
+	w.Objects = []shape.Shape{s}
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	result := NewPathTracer().Radiance(w, r)
+	expected := colour.New(0.05, 0.4, 0.64)
+	if result.Equal(expected) != true {
+		t.Errorf("Path traced radiance through absorbing glass was %v, expected %v.", result, expected)
+	}
+}
+
+func TestPathTracerHiddenFromCamera(t *testing.T) {
+	w := New()
+	w.Background = environment.NewSolid(colour.New(0.2, 0.4, 1))
+	s := shape.NewSphere()
+	m := material.New()
+	m.Emission = colour.New(2, 1, 0.5)
+	s.SetMaterial(m)
+	s.SetVisibleToCamera(false)
+	w.Objects = []shape.Shape{s}
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	result := NewPathTracer().Radiance(w, r)
+	if result.Equal(w.Background.ColourAt(r.Direction)) != true {
+		t.Errorf("Path traced radiance past a hidden emitter was %v, expected the background.", result)
+	}
+}
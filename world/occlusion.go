@@ -0,0 +1,48 @@
+package world
+
+import (
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/sampling"
+)
+
+// AmbientOcclusion returns the fraction of a number of cosine weighted rays
+// from a computed intersection which travel distance without hitting an object.
+// When distance is zero or less any hit occludes. Only objects which cast
+// shadows occlude, and objects which receive no shadows are never occluded.
+func AmbientOcclusion(w World, comps Comps, samples int, distance float64) float64 {
+	if samples <= 0 || !comps.Object.ReceivesShadows() {
+		return 1
+	}
+	open := 0
+	for i := 0; i < samples; i++ {
+		direction := sampling.CosineHemisphere(comps.NormalV, sampling.Float64(), sampling.Float64())
+		h, err := shadowHit(w, ray.NewAtTime(comps.OverPoint, direction, comps.Time))
+		if err != nil || (distance > 0 && h.T > distance) {
+			open++
+		}
+	}
+	return float64(open) / float64(samples)
+}
+
+// Occlusion renders the ambient occlusion of each hit in grey, with misses white.
+type Occlusion struct {
+	Samples  int
+	Distance float64
+}
+
+// Radiance returns the ambient occlusion seen along a ray as a grey colour.
+func (o Occlusion) Radiance(w World, r ray.Ray) colour.Colour {
+	hit, err := visibleHit(w, r, true)
+	if err != nil {
+		return colour.New(1, 1, 1)
+	}
+	v := AmbientOcclusion(w, PrepareComputations(hit, r), o.Samples, o.Distance)
+	return colour.New(v, v, v)
+}
+
+// NewOcclusion returns an ambient occlusion render mode casting samples rays up
+// to distance from each hit.
+func NewOcclusion(samples int, distance float64) Occlusion {
+	return Occlusion{Samples: samples, Distance: distance}
+}
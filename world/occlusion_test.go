@@ -0,0 +1,92 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/lukeshiner/raytrace/colour"
+	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/light"
+	"github.com/lukeshiner/raytrace/matrix"
+	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/shape"
+	"github.com/lukeshiner/raytrace/vector"
+)
+
+// roomWorld returns a world with a floor and a ceiling one unit above it.
+func roomWorld() World {
+	w := New()
+	ceiling := shape.NewPlane()
+	ceiling.SetTransform(matrix.TranslationMatrix(0, 1, 0))
+	w.Objects = []shape.Shape{shape.NewPlane(), ceiling}
+	return w
+}
+
+func TestAmbientOcclusion(t *testing.T) {
+	var tests = []struct {
+		ceiling  bool
+		samples  int
+		distance float64
+		expected float64
+	}{
+		{ceiling: false, samples: 16, distance: 0, expected: 1},
+		{ceiling: true, samples: 16, distance: 0, expected: 0},
+		{
+			// The ceiling is out of reach.
+			ceiling: true, samples: 16, distance: 0.5, expected: 1,
+		},
+		{ceiling: true, samples: 0, distance: 0, expected: 1},
+	}
+	for _, test := range tests {
+		w := roomWorld()
+		if !test.ceiling {
+			w.Objects = w.Objects[:1]
+		}
+		r := ray.New(vector.NewPoint(0, 0.5, -0.5), vector.NewVector(0, -1, 1))
+		comps := PrepareComputations(shape.NewIntersection(0.5, w.Objects[0]), r)
+		result := AmbientOcclusion(w, comps, test.samples, test.distance)
+		if comparison.EpsilonEqual(result, test.expected) != true {
+			t.Errorf(
+				"Ambient occlusion with ceiling %v, %d samples and distance %v was %v, expected %v.",
+				test.ceiling, test.samples, test.distance, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestShadeHitOcclusion(t *testing.T) {
+	w := roomWorld()
+	w.Lights = []light.Light{light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0.5, 0))}
+	r := ray.New(vector.NewPoint(0, 0.5, -0.5), vector.NewVector(0, -1, 1))
+	comps := PrepareComputations(shape.NewIntersection(0.5, w.Objects[0]), r)
+	open := ShadeHit(w, comps)
+	w.OcclusionSamples = 16
+	occluded := ShadeHit(w, comps)
+	expected := colour.New(0.1, 0.1, 0.1)
+	if result := open.Sub(occluded); result.Equal(expected) != true {
+		t.Errorf("Occlusion took %v from shade hit, expected %v.", result, expected)
+	}
+}
+
+func TestOcclusionRadiance(t *testing.T) {
+	w := roomWorld()
+	var tests = []struct {
+		ray      ray.Ray
+		expected colour.Colour
+	}{
+		{
+			ray:      ray.New(vector.NewPoint(0, 0.5, 0), vector.NewVector(0, -1, 0)),
+			expected: colour.New(0, 0, 0),
+		},
+		{
+			// A miss is white.
+			ray:      ray.New(vector.NewPoint(0, 0.5, 0), vector.NewVector(1, 0, 0)),
+			expected: colour.New(1, 1, 1),
+		},
+	}
+	for _, test := range tests {
+		result := NewOcclusion(16, 0).Radiance(w, test.ray)
+		if result.Equal(test.expected) != true {
+			t.Errorf("Occlusion radiance along %+v was %v, expected %v.", test.ray, result, test.expected)
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package world
 
 import (
+	"github.com/lukeshiner/raytrace/bsdf"
 	"github.com/lukeshiner/raytrace/colour"
 	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/environment"
 	"github.com/lukeshiner/raytrace/light"
 	"github.com/lukeshiner/raytrace/material"
 	"github.com/lukeshiner/raytrace/matrix"
 	"github.com/lukeshiner/raytrace/ray"
+	"github.com/lukeshiner/raytrace/sampling"
 	"github.com/lukeshiner/raytrace/shape"
 	"github.com/lukeshiner/raytrace/vector"
 )
@@ -15,11 +18,25 @@ import (
 type World struct {
 	Objects []shape.Shape
 	Lights  []light.Light
+	// Background is seen by rays which miss every object. When it is nil they
+	// are black.
+	Background environment.Environment
+	// EnvironmentSamples is the number of rays used to light diffuse surfaces
+	// from the background. When it is zero the background casts no light.
+	EnvironmentSamples int
+	// OcclusionSamples is the number of rays used to find how much of the
+	// ambient light reaches a surface, looking for objects within
+	// OcclusionDistance. When it is zero ambient light is not occluded.
+	OcclusionSamples  int
+	OcclusionDistance float64
+	// MaxDepth is the most times a ray is reflected or refracted by specular
+	// surfaces in ShadeHit.
+	MaxDepth int
 }
 
 // New returns an empty world.
 func New() World {
-	return World{}
+	return World{MaxDepth: 5}
 }
 
 // Default returns a default world with a light at two spheres.
@@ -53,65 +70,307 @@ type Comps struct {
 	T                               float64
 	Object                          shape.Shape
 	Point, EyeV, NormalV, OverPoint vector.Vector
-	Inside                          bool
+	// UnderPoint is just below the surface, for rays leaving into the object.
+	UnderPoint vector.Vector
+	// GeometricNormalV is the normal of the surface itself on the eye's side,
+	// which NormalV is unless the material has a bump map.
+	GeometricNormalV vector.Vector
+	// Tangent is the unit tangent of the surface. It is only found when the
+	// material's BSDF or bump map needs it, and is zero otherwise.
+	Tangent vector.Vector
+	Inside  bool
+	// Medium is the object the ray travelled through to reach the hit, or nil
+	// when it travelled through none.
+	Medium shape.Shape
+	// Time is the time of the ray which made the intersection.
+	Time float64
 }
 
-// PrepareComputations returns a Comps for an intersection and a ray.
+// PrepareComputations returns a Comps for an intersection and a ray, which came
+// through the object when it hit it from the inside and through nothing
+// otherwise.
 func PrepareComputations(i shape.Intersection, r ray.Ray) Comps {
 	inside := false
 	point := r.Position(i.T)
 	eyeV := r.Direction.Negate()
-	normalV := shape.NormalAt(i.Object, point)
-	if vector.DotProduct(normalV, eyeV) < 0 {
+	geometric := shape.NormalAtTime(i.Object, point, r.Time)
+	normalV := geometric
+	var tangent vector.Vector
+	if m := i.Object.Material(); m.Bump != nil || bsdf.NeedsTangent(m.Surface()) {
+		tangent = shape.TangentAtTime(i.Object, point, r.Time)
+		normalV = shape.ShadingNormalAtTime(i.Object, point, geometric, tangent, r.Time)
+		if normalV != geometric {
+			tangent = vector.Subtract(tangent, normalV.ScalarMultiply(vector.DotProduct(tangent, normalV)))
+			tangent = tangent.Normalize()
+		}
+	}
+	if vector.DotProduct(geometric, eyeV) < 0 {
 		inside = true
+		geometric = geometric.Negate()
 		normalV = normalV.Negate()
 	}
-	overPoint := vector.Add(point, normalV.ScalarMultiply(comparison.EPSLION))
+	// Rays leave from just off the real surface, so bumps don't cause acne.
+	overPoint := vector.Add(point, geometric.ScalarMultiply(comparison.EPSLION))
+	underPoint := vector.Subtract(point, geometric.ScalarMultiply(comparison.EPSLION))
+	var medium shape.Shape
+	if inside {
+		medium = i.Object
+	}
 	return Comps{
 		T: i.T, Object: i.Object, Point: point, EyeV: eyeV, NormalV: normalV, Inside: inside,
-		OverPoint: overPoint,
+		OverPoint: overPoint, UnderPoint: underPoint, GeometricNormalV: geometric,
+		Tangent: tangent, Medium: medium, Time: r.Time,
+	}
+}
+
+// prepareComputations returns a Comps for the hit of a ray among all of its
+// intersections, which show the object it came through.
+func prepareComputations(hit shape.Intersection, r ray.Ray, intersections shape.Intersections) Comps {
+	comps := PrepareComputations(hit, r)
+	var inside containers
+	for _, i := range intersections.Intersections {
+		if i.T >= hit.T {
+			break
+		}
+		inside = inside.cross(i)
+	}
+	comps.Medium = inside.innermost()
+	return comps
+}
+
+// containers holds the intersections where a ray went into the objects it is
+// inside, innermost last.
+type containers []shape.Intersection
+
+// cross returns the containers after the ray crosses the surface of an
+// intersection, going into the object if it was outside it and out of it
+// otherwise. The surfaces of a CSG shape's children bound the CSG shape as a
+// whole.
+func (c containers) cross(i shape.Intersection) containers {
+	solid := shape.Solid(i.Object).ID()
+	for j, entry := range c {
+		if shape.Solid(entry.Object).ID() == solid {
+			return append(c[:j:j], c[j+1:]...)
+		}
+	}
+	return append(c, i)
+}
+
+// innermost returns the object the ray went into last, or nil when it is inside
+// none.
+func (c containers) innermost() shape.Shape {
+	if len(c) == 0 {
+		return nil
+	}
+	return c[len(c)-1].Object
+}
+
+// transmittance returns the fraction of light not absorbed over distance inside
+// the innermost object.
+func (c containers) transmittance(distance float64) colour.Colour {
+	if len(c) == 0 {
+		return colour.New(1, 1, 1)
+	}
+	return c[len(c)-1].Object.Material().Transmittance(distance)
+}
+
+// Surface returns the BSDF of the object's material, oriented along the
+// surface's tangent.
+func (comps Comps) Surface() bsdf.BSDF {
+	return bsdf.Orient(comps.Object.Material().Surface(), comps.Tangent)
+}
+
+// Normal returns the normal of the surface pointing out of the object.
+func (comps Comps) Normal() vector.Vector {
+	if comps.Inside {
+		return comps.NormalV.Negate()
 	}
+	return comps.NormalV
+}
+
+// Origin returns a point to start a ray leaving the surface in direction.
+func (comps Comps) Origin(direction vector.Vector) vector.Vector {
+	if vector.DotProduct(direction, comps.GeometricNormalV) < 0 {
+		return comps.UnderPoint
+	}
+	return comps.OverPoint
 }
 
 // ShadeHit returns the colour for a computed intersection.
 func ShadeHit(world World, comps Comps) colour.Colour {
-	var lightColour colour.Colour
-	var shadowed bool
+	return shadeHit(world, comps, world.MaxDepth)
+}
+
+// shadeHit returns the colour for a computed intersection, following specular
+// reflections and refractions for up to remaining more surfaces.
+func shadeHit(world World, comps Comps, remaining int) colour.Colour {
+	var lightColour, transmittance colour.Colour
 	c := colour.New(0, 0, 0)
+	m := comps.Object.Material()
+	if world.OcclusionSamples > 0 {
+		m.Ambient *= AmbientOcclusion(
+			world, comps, world.OcclusionSamples, world.OcclusionDistance)
+	}
+	surface := comps.Surface()
 	for i := 0; i < len(world.Lights); i++ {
-		shadowed = IsShadowed(world, comps.OverPoint, world.Lights[i])
-		lightColour = shape.Lighting(
-			comps.Object.Material(), world.Lights[i], comps.Point, comps.EyeV, comps.NormalV,
-			shadowed,
+		transmittance = lightReaching(world, comps, world.Lights[i])
+		lightColour = shape.FilteredLighting(
+			m, surface, world.Lights[i], comps.Point, comps.EyeV, comps.NormalV, transmittance,
 		)
 		c = c.Add(lightColour)
 	}
-	return c
+	c = c.Add(m.Emission)
+	if specular, ok := surface.(bsdf.SpecularBSDF); ok && remaining > 0 {
+		for _, s := range specular.SpecularSamples(comps.EyeV, comps.Normal()) {
+			r := ray.NewAtTime(comps.Origin(s.Direction), s.Direction, comps.Time)
+			c = c.Add(s.Weight.Mult(colourAt(world, r, remaining-1, false)))
+		}
+	}
+	return c.Add(EnvironmentLighting(world, comps))
+}
+
+// EnvironmentLighting returns the light reflected from the world's background at
+// a computed intersection, estimated by importance sampling the background.
+func EnvironmentLighting(w World, comps Comps) colour.Colour {
+	c := colour.New(0, 0, 0)
+	if w.Background == nil || w.EnvironmentSamples <= 0 {
+		return c
+	}
+	surface := comps.Surface()
+	for i := 0; i < w.EnvironmentSamples; i++ {
+		direction, pdf := w.Background.Sample(sampling.Float64(), sampling.Float64())
+		cosTheta := vector.DotProduct(direction, comps.NormalV)
+		if pdf <= 0 || cosTheta <= 0 {
+			continue
+		}
+		r := ray.NewAtTime(comps.OverPoint, direction, comps.Time)
+		if _, err := shadowHit(w, r); err == nil && comps.Object.ReceivesShadows() {
+			continue
+		}
+		reflected := surface.Eval(direction, comps.EyeV, comps.Normal()).ScalarMult(cosTheta / pdf)
+		c = c.Add(w.Background.ColourAt(direction).Mult(reflected))
+	}
+	return c.ScalarMult(1 / float64(w.EnvironmentSamples))
 }
 
-// ColourAt returns the colour for a given ray in a given world.
+// ColourAt returns the colour for a given ray from the camera in a given world.
 func ColourAt(w World, r ray.Ray) colour.Colour {
-	intersections := IntersectWorld(w, r)
+	return colourAt(w, r, w.MaxDepth, true)
+}
+
+// colourAt returns the colour for a ray from the camera when primary is true,
+// otherwise for a reflected or refracted ray.
+func colourAt(w World, r ray.Ray, remaining int, primary bool) colour.Colour {
+	intersections := visibleIntersections(w, r, primary)
 	hit, err := intersections.Hit()
 	if err != nil {
-		return colour.New(0, 0, 0)
+		return background(w, r)
 	}
-	comps := PrepareComputations(hit, r)
-	return ShadeHit(w, comps)
+	comps := prepareComputations(hit, r, intersections)
+	return Absorption(comps, r).Mult(shadeHit(w, comps, remaining))
+}
+
+// Absorption returns the fraction of the light from a computed intersection
+// which is not absorbed on its way along the ray through the object it came
+// through.
+func Absorption(comps Comps, r ray.Ray) colour.Colour {
+	if comps.Medium == nil {
+		return colour.New(1, 1, 1)
+	}
+	return comps.Medium.Material().Transmittance(comps.T * r.Direction.Magnitude())
+}
+
+// visibleIntersections returns the intersections of a ray with objects which it
+// can see, directly from the camera when primary is true, otherwise in
+// reflections and refractions.
+func visibleIntersections(w World, r ray.Ray, primary bool) shape.Intersections {
+	intersections := IntersectWorld(w, r)
+	visible := shape.NewIntersections()
+	for _, i := range intersections.Intersections {
+		if (primary && i.Object.VisibleToCamera()) || (!primary && i.Object.VisibleInReflections()) {
+			visible.Intersections = append(visible.Intersections, i)
+		}
+	}
+	return visible
+}
+
+// visibleHit returns the first hit of a ray on an object which it can see.
+func visibleHit(w World, r ray.Ray, primary bool) (shape.Intersection, error) {
+	intersections := visibleIntersections(w, r, primary)
+	return intersections.Hit()
+}
+
+// shadowIntersections returns the intersections of a ray with objects which
+// cast shadows.
+func shadowIntersections(w World, r ray.Ray) shape.Intersections {
+	intersections := IntersectWorld(w, r)
+	casters := shape.NewIntersections()
+	for _, i := range intersections.Intersections {
+		if i.Object.CastsShadows() {
+			casters.Intersections = append(casters.Intersections, i)
+		}
+	}
+	return casters
+}
+
+// shadowHit returns the first hit of a ray on an object which casts shadows.
+func shadowHit(w World, r ray.Ray) (shape.Intersection, error) {
+	intersections := shadowIntersections(w, r)
+	return intersections.Hit()
+}
+
+// lightReaching returns the fraction of the light from l reaching a computed
+// intersection, which is all of it when the object receives no shadows.
+func lightReaching(w World, comps Comps, l light.Light) colour.Colour {
+	if !comps.Object.ReceivesShadows() {
+		return colour.New(1, 1, 1)
+	}
+	return shadowTransmittance(w, comps.OverPoint, l, comps.Time)
 }
 
 // IsShadowed returns true if a point in the world is shadowed from light.
 func IsShadowed(w World, p vector.Vector, l light.Light) bool {
-	v := vector.Subtract(l.Position(), p)
-	distance := v.Magnitude()
-	direction := v.Normalize()
+	return isShadowed(w, p, l, 0)
+}
 
-	r := ray.New(p, direction)
-	intersections := IntersectWorld(w, r)
+// isShadowed returns true if a point in the world is shadowed from light at time.
+func isShadowed(w World, p vector.Vector, l light.Light, time float64) bool {
+	return shadowTransmittance(w, p, l, time) == colour.New(0, 0, 0)
+}
+
+// ShadowTransmittance returns the fraction of the light from l which reaches a
+// point in the world. Light passes objects which cast no shadows, and is tinted
+// by transparent objects and absorbed inside them.
+func ShadowTransmittance(w World, p vector.Vector, l light.Light) colour.Colour {
+	return shadowTransmittance(w, p, l, 0)
+}
 
-	h, err := intersections.Hit()
-	if err == nil && h.T < distance {
-		return true
+// shadowTransmittance returns the fraction of the light from l which reaches a
+// point in the world at time.
+func shadowTransmittance(w World, p vector.Vector, l light.Light, time float64) colour.Colour {
+	direction, distance := l.DirectionFrom(p)
+	r := ray.NewAtTime(p, direction, time)
+	transmittance := colour.New(1, 1, 1)
+	// Each stretch of the ray is absorbed by the innermost object it is inside.
+	var inside containers
+	last := 0.0
+	for _, i := range shadowIntersections(w, r).Intersections {
+		if i.T >= distance {
+			break
+		}
+		if i.T >= 0 {
+			transmitter, ok := i.Object.Material().Surface().(bsdf.Transmitter)
+			if !ok {
+				return colour.New(0, 0, 0)
+			}
+			transmittance = transmittance.Mult(inside.transmittance(i.T - last))
+			transmittance = transmittance.Mult(transmitter.Transmission())
+			if transmittance == colour.New(0, 0, 0) {
+				return transmittance
+			}
+			last = i.T
+		}
+		inside = inside.cross(i)
 	}
-	return false
+	return transmittance.Mult(inside.transmittance(distance - last))
 }
@@ -1,10 +1,15 @@
 package world
 
 import (
+	"math"
 	"testing"
 
+	"github.com/lukeshiner/raytrace/bsdf"
+	"github.com/lukeshiner/raytrace/bump"
+	"github.com/lukeshiner/raytrace/canvas"
 	"github.com/lukeshiner/raytrace/colour"
 	"github.com/lukeshiner/raytrace/comparison"
+	"github.com/lukeshiner/raytrace/environment"
 	"github.com/lukeshiner/raytrace/light"
 	"github.com/lukeshiner/raytrace/material"
 	"github.com/lukeshiner/raytrace/matrix"
@@ -266,6 +271,146 @@ func TestInShadow(t *testing.T) {
 	}
 }
 
+func TestShadowTransmittance(t *testing.T) {
+	opaque := shape.NewSphere()
+	hidden := shape.NewSphere()
+	hidden.SetCastsShadows(false)
+	glass := shape.NewSphere()
+	m := material.New()
+	m.BSDF = bsdf.Dielectric{IOR: 1.5, Tint: colour.New(1, 0.5, 0)}
+	glass.SetMaterial(m)
+	absorbing := shape.NewSphere()
+	m = material.New()
+	m.BSDF = bsdf.NewDielectric(1.5)
+	m.Absorption, m.Density = colour.New(0.5, 1, 0.8), 1
+	absorbing.SetMaterial(m)
+	coated := shape.NewSphere()
+	m = material.New()
+	m.BSDF = bsdf.NewDielectric(1.5)
+	m.Coat = material.NewCoat(1.5, 0)
+	coated.SetMaterial(m)
+	l := light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, 5))
+	var tests = []struct {
+		object   shape.Shape
+		point    vector.Vector
+		expected colour.Colour
+	}{
+		{object: opaque, point: vector.NewPoint(0, 0, -5), expected: colour.New(0, 0, 0)},
+		{object: hidden, point: vector.NewPoint(0, 0, -5), expected: colour.New(1, 1, 1)},
+		// Light is tinted going into and out of glass.
+		{object: glass, point: vector.NewPoint(0, 0, -5), expected: colour.New(1, 0.25, 0)},
+		{object: glass, point: vector.NewPoint(0, 0, 0), expected: colour.New(1, 0.5, 0)},
+		{object: absorbing, point: vector.NewPoint(0, 0, -5), expected: colour.New(0.25, 1, 0.64)},
+		{object: absorbing, point: vector.NewPoint(0, 0, 0), expected: colour.New(0.5, 1, 0.8)},
+		// Coats on glass reflect a little of the light at each surface.
+		{object: coated, point: vector.NewPoint(0, 0, -5), expected: colour.New(0.9216, 0.9216, 0.9216)},
+	}
+	for _, test := range tests {
+		w := New()
+		w.Objects = []shape.Shape{test.object}
+		w.Lights = []light.Light{l}
+		result := ShadowTransmittance(w, test.point, l)
+		if result.Equal(test.expected) != true {
+			t.Errorf(
+				"Shadow transmittance at %v past %+v was %v, expected %v.",
+				test.point, test.object.Material(), result, test.expected,
+			)
+		}
+	}
+}
+
+func TestShadeHitColouredShadow(t *testing.T) {
+	w := New()
+	w.Lights = []light.Light{light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 10, 0))}
+	floor := shape.NewPlane()
+	glass := shape.NewSphere()
+	glass.SetTransform(matrix.TranslationMatrix(0, 3, 0))
+	m := material.New()
+	m.BSDF = bsdf.Dielectric{IOR: 1.5, Tint: colour.New(1, 0.5, 0)}
+	glass.SetMaterial(m)
+	w.Objects = []shape.Shape{floor, glass}
+	r := ray.New(vector.NewPoint(0, 1, 0), vector.NewVector(0, -1, 0))
+	comps := PrepareComputations(shape.NewIntersection(1, floor), r)
+	result := ShadeHit(w, comps)
+	// The ambient light and the light through the glass, tinted twice.
+	expected := colour.New(1.9, 0.55, 0.1)
+	if result.Equal(expected) != true {
+		t.Errorf("Shadow of tinted glass was %v, expected %v.", result, expected)
+	}
+}
+
+func TestColourAtVisibility(t *testing.T) {
+	var tests = []struct {
+		camera, reflections bool
+		direct, refracted   colour.Colour
+	}{
+		{camera: true, reflections: true, direct: colour.New(1, 1, 1), refracted: colour.New(1, 1, 1)},
+		{camera: false, reflections: true, direct: colour.New(0.2, 0.4, 1), refracted: colour.New(1, 1, 1)},
+		{camera: true, reflections: false, direct: colour.New(1, 1, 1), refracted: colour.New(0.2, 0.4, 1)},
+	}
+	for _, test := range tests {
+		w := New()
+		w.Background = environment.NewSolid(colour.New(0.2, 0.4, 1))
+		emitter := shape.NewSphere()
+		emitter.SetTransform(matrix.TranslationMatrix(0, 0, 5))
+		m := material.New()
+		m.Ambient = 0
+		m.Emission = colour.New(1, 1, 1)
+		emitter.SetMaterial(m)
+		emitter.SetVisibleToCamera(test.camera)
+		emitter.SetVisibleInReflections(test.reflections)
+		w.Objects = []shape.Shape{emitter}
+		r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+		if result := ColourAt(w, r); result.Equal(test.direct) != true {
+			t.Errorf(
+				"ColourAt seen by the camera %v and in reflections %v was %v, expected %v.",
+				test.camera, test.reflections, result, test.direct,
+			)
+		}
+		// Seen through a clear sphere which doesn't bend light.
+		glass := shape.NewSphere()
+		m = material.New()
+		m.BSDF = bsdf.NewDielectric(1)
+		glass.SetMaterial(m)
+		w.Objects = append(w.Objects, glass)
+		if result := ColourAt(w, r); result.Equal(test.refracted) != true {
+			t.Errorf(
+				"ColourAt through glass seen by the camera %v and in reflections %v was %v, expected %v.",
+				test.camera, test.reflections, result, test.refracted,
+			)
+		}
+	}
+}
+
+func TestShadeHitShadowFlags(t *testing.T) {
+	var tests = []struct {
+		casts, receives bool
+		expected        colour.Colour
+	}{
+		{casts: true, receives: true, expected: colour.New(0.1, 0.1, 0.1)},
+		{casts: false, receives: true, expected: colour.New(1.9, 1.9, 1.9)},
+		{casts: true, receives: false, expected: colour.New(1.9, 1.9, 1.9)},
+	}
+	for _, test := range tests {
+		w := New()
+		w.Lights = []light.Light{light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 10, 0))}
+		floor := shape.NewPlane()
+		floor.SetReceivesShadows(test.receives)
+		blocker := shape.NewSphere()
+		blocker.SetTransform(matrix.TranslationMatrix(0, 3, 0))
+		blocker.SetCastsShadows(test.casts)
+		w.Objects = []shape.Shape{floor, blocker}
+		r := ray.New(vector.NewPoint(0, 1, 0), vector.NewVector(0, -1, 0))
+		comps := PrepareComputations(shape.NewIntersection(1, floor), r)
+		if result := ShadeHit(w, comps); result.Equal(test.expected) != true {
+			t.Errorf(
+				"ShadeHit with a blocker casting shadows %v on a floor receiving them %v was %v, expected %v.",
+				test.casts, test.receives, result, test.expected,
+			)
+		}
+	}
+}
+
 func TestOverPoint(t *testing.T) {
 	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
 	s := shape.NewSphere()
@@ -280,3 +425,328 @@ func TestOverPoint(t *testing.T) {
 		t.Errorf("Over Point %v too high.", result)
 	}
 }
+
+func TestColourAtBackground(t *testing.T) {
+	w := Default()
+	w.Background = environment.NewGradient(colour.New(0, 0, 0), colour.New(0.2, 0.4, 1))
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 2, 0))
+	expected := colour.New(0.2, 0.4, 1)
+	result := ColourAt(w, r)
+	if result.Equal(expected) != true {
+		t.Errorf("ColourAt for a miss returned %v, expected %v.", result, expected)
+	}
+}
+
+func TestEnvironmentLighting(t *testing.T) {
+	var tests = []struct {
+		background environment.Environment
+		samples    int
+		expected   colour.Colour
+	}{
+		{
+			// No background casts no light.
+			background: nil,
+			samples:    16,
+			expected:   colour.New(0, 0, 0),
+		},
+		{
+			// No samples casts no light.
+			background: environment.NewSolid(colour.New(1, 1, 1)),
+			samples:    0,
+			expected:   colour.New(0, 0, 0),
+		},
+		{
+			// A uniform background reflects its colour times the diffuse albedo.
+			background: environment.NewSolid(colour.New(1, 0.5, 1)),
+			samples:    4000,
+			expected:   colour.New(0.9, 0.45, 0.9),
+		},
+	}
+	for _, test := range tests {
+		w := New()
+		w.Objects = []shape.Shape{diffuseSphere()}
+		w.Background = test.background
+		w.EnvironmentSamples = test.samples
+		r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+		comps := PrepareComputations(shape.NewIntersection(4, w.Objects[0]), r)
+		result := EnvironmentLighting(w, comps)
+		if math.Abs(result.Red-test.expected.Red) > 0.05 ||
+			math.Abs(result.Green-test.expected.Green) > 0.05 ||
+			math.Abs(result.Blue-test.expected.Blue) > 0.05 {
+			t.Errorf(
+				"EnvironmentLighting with %+v returned %v, expected %v.",
+				test.background, result, test.expected,
+			)
+		}
+	}
+}
+
+func TestPrepareComputationsTime(t *testing.T) {
+	s := shape.NewSphere()
+	s.SetEndTransform(matrix.TranslationMatrix(0, 2, 0))
+	r := ray.NewAtTime(vector.NewPoint(-5, 1, 0), vector.NewVector(1, 0, 0), 0.5)
+	comps := PrepareComputations(shape.NewIntersection(4, s), r)
+	if comps.Time != 0.5 {
+		t.Errorf("Comps.Time was %v, expected 0.5.", comps.Time)
+	}
+	if vector.Equal(comps.NormalV, vector.NewVector(-1, 0, 0)) != true {
+		t.Errorf("Comps.NormalV was %+v, expected the normal at time 0.5.", comps.NormalV)
+	}
+}
+
+func TestShadeHitEmission(t *testing.T) {
+	w := New()
+	s := shape.NewSphere()
+	m := material.New()
+	m.Emission = colour.New(2, 1, 0.5)
+	s.SetMaterial(m)
+	w.Objects = []shape.Shape{s}
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	comps := PrepareComputations(shape.NewIntersection(4, s), r)
+	result := ShadeHit(w, comps)
+	if result.Equal(m.Emission) != true {
+		t.Errorf("Shade hit on an emitter returned %v, expected %v.", result, m.Emission)
+	}
+}
+
+func TestShadeHitSpecular(t *testing.T) {
+	var tests = []struct {
+		name     string
+		bsdf     bsdf.BSDF
+		maxDepth int
+		expected colour.Colour
+	}{
+		{
+			// Matched glass lets the background straight through.
+			name: "matched glass", bsdf: bsdf.NewDielectric(1), maxDepth: 5,
+			expected: colour.New(0.2, 0.4, 1),
+		},
+		{
+			name: "tinted glass", bsdf: bsdf.Dielectric{IOR: 1, Tint: colour.New(1, 0.5, 0)},
+			maxDepth: 5, expected: colour.New(0.2, 0.1, 0),
+		},
+		{
+			// A clear coat over black reflects 4% head on.
+			name:     "clear coat",
+			bsdf:     bsdf.NewClearCoat(bsdf.Lambert{Albedo: colour.New(0, 0, 0)}, 1.5, 0),
+			maxDepth: 5, expected: colour.New(0.008, 0.016, 0.04),
+		},
+		{
+			// Rays stop when they have run out of depth.
+			name: "no depth", bsdf: bsdf.NewDielectric(1), maxDepth: 1,
+			expected: colour.New(0, 0, 0),
+		},
+	}
+	for _, test := range tests {
+		w := New()
+		w.MaxDepth = test.maxDepth
+		w.Background = environment.NewSolid(colour.New(0.2, 0.4, 1))
+		s := shape.NewSphere()
+		m := material.New()
+		m.BSDF = test.bsdf
+		s.SetMaterial(m)
+		w.Objects = []shape.Shape{s}
+		r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+		result := ColourAt(w, r)
+		if result.Equal(test.expected) != true {
+			t.Errorf("Colour through %s was %v, expected %v.", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestCompsSurface(t *testing.T) {
+	s := shape.NewSphere()
+	m := material.NewMetallicRoughness(colour.New(1, 1, 1), 1, 0.6)
+	m.BitangentRoughness = 0.1
+	s.SetMaterial(m)
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	comps := PrepareComputations(shape.NewIntersection(4, s), r)
+	tangent := vector.NewVector(1, 0, 0)
+	if vector.Equal(comps.Tangent, tangent) != true {
+		t.Errorf("Comps.Tangent was %+v, expected %+v.", comps.Tangent, tangent)
+	}
+	expected := bsdf.MetallicRoughness{
+		Colour: colour.New(1, 1, 1), Metallic: 1, Roughness: 0.6, BitangentRoughness: 0.1,
+		Tangent: tangent,
+	}
+	if result := comps.Surface(); result != expected {
+		t.Errorf("Comps surface was %+v, expected %+v.", result, expected)
+	}
+	// Isotropic surfaces are not given a tangent.
+	m.BitangentRoughness = 0
+	s.SetMaterial(m)
+	comps = PrepareComputations(shape.NewIntersection(4, s), r)
+	if vector.Equal(comps.Tangent, vector.Vector{}) != true {
+		t.Errorf("Comps.Tangent for an isotropic surface was %+v, expected zero.", comps.Tangent)
+	}
+}
+
+func TestPrepareComputationsBump(t *testing.T) {
+	image := canvas.New(1, 1)
+	image.WritePixel(0, 0, colour.New(1, 0.5, 1))
+	m := material.New()
+	m.Bump = bump.NewNormalMap(&image)
+	p := shape.NewPlane()
+	p.SetMaterial(m)
+	var tests = []struct {
+		ray                        ray.Ray
+		normal, geometric, tangent vector.Vector
+	}{
+		{
+			ray:       ray.New(vector.NewPoint(0.5, 1, 0.5), vector.NewVector(0, -1, 0)),
+			normal:    vector.NewVector(math.Sqrt2/2, math.Sqrt2/2, 0),
+			geometric: vector.NewVector(0, 1, 0),
+			tangent:   vector.NewVector(math.Sqrt2/2, -math.Sqrt2/2, 0),
+		},
+		{
+			ray:       ray.New(vector.NewPoint(0.5, -1, 0.5), vector.NewVector(0, 1, 0)),
+			normal:    vector.NewVector(-math.Sqrt2/2, -math.Sqrt2/2, 0),
+			geometric: vector.NewVector(0, -1, 0),
+			tangent:   vector.NewVector(math.Sqrt2/2, -math.Sqrt2/2, 0),
+		},
+	}
+	for _, test := range tests {
+		comps := PrepareComputations(shape.NewIntersection(1, p), test.ray)
+		if vector.Equal(comps.NormalV, test.normal) != true {
+			t.Errorf("Comps.NormalV was %+v, expected %+v.", comps.NormalV, test.normal)
+		}
+		if vector.Equal(comps.GeometricNormalV, test.geometric) != true {
+			t.Errorf("Comps.GeometricNormalV was %+v, expected %+v.", comps.GeometricNormalV, test.geometric)
+		}
+		if vector.Equal(comps.Tangent, test.tangent) != true {
+			t.Errorf("Comps.Tangent was %+v, expected %+v.", comps.Tangent, test.tangent)
+		}
+		// Rays still leave from just off the flat surface.
+		over := vector.Add(comps.Point, test.geometric.ScalarMultiply(comparison.EPSLION))
+		if vector.Equal(comps.OverPoint, over) != true {
+			t.Errorf("Comps.OverPoint was %+v, expected %+v.", comps.OverPoint, over)
+		}
+	}
+}
+
+func TestAbsorption(t *testing.T) {
+	s := shape.NewSphere()
+	m := material.New()
+	m.Ambient = 0
+	m.Emission = colour.New(1, 1, 1)
+	m.Absorption, m.Density = colour.New(0.5, 0.8, 1), 1
+	s.SetMaterial(m)
+	s.SetTransform(matrix.ScalingMatrix(2, 2, 2))
+	w := New()
+	w.Objects = []shape.Shape{s}
+	var tests = []struct {
+		ray      ray.Ray
+		expected colour.Colour
+	}{
+		// Light from the outside of the object is not absorbed.
+		{
+			ray:      ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1)),
+			expected: colour.New(1, 1, 1),
+		},
+		// Light from the far side is absorbed over the distance inside.
+		{
+			ray:      ray.New(vector.NewPoint(0, 0, 0), vector.NewVector(0, 0, 1)),
+			expected: colour.New(0.25, 0.64, 1),
+		},
+		{
+			ray:      ray.New(vector.NewPoint(0, 0, 1), vector.NewVector(0, 0, 1)),
+			expected: colour.New(0.5, 0.8, 1),
+		},
+	}
+	for _, test := range tests {
+		result := ColourAt(w, test.ray)
+		if result.Equal(test.expected) != true {
+			t.Errorf("ColourAt %+v through an absorbing object was %v, expected %v.", test.ray, result, test.expected)
+		}
+	}
+}
+
+func TestNestedAbsorption(t *testing.T) {
+	outer := shape.NewSphere()
+	m := material.New()
+	m.Ambient = 0
+	m.BSDF = bsdf.NewDielectric(1)
+	m.Absorption, m.Density = colour.New(0.5, 0.5, 0.5), 1
+	outer.SetMaterial(m)
+	outer.SetTransform(matrix.ScalingMatrix(2, 2, 2))
+	inner := shape.NewSphere()
+	m.Absorption = colour.New(0.25, 0.25, 0.25)
+	inner.SetMaterial(m)
+	l := light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, 5))
+	w := New()
+	w.Objects = []shape.Shape{outer, inner}
+	w.Lights = []light.Light{l}
+	w.Background = environment.NewSolid(colour.New(1, 1, 1))
+	// Each stretch of the ray is absorbed by the innermost sphere only, two
+	// through the outer sphere and two through the inner one.
+	expected := colour.New(0.015625, 0.015625, 0.015625)
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	if result := ColourAt(w, r); result.Equal(expected) != true {
+		t.Errorf("ColourAt through nested spheres was %v, expected %v.", result, expected)
+	}
+	result := ShadowTransmittance(w, vector.NewPoint(0, 0, -5), l)
+	if result.Equal(expected) != true {
+		t.Errorf("Shadow transmittance through nested spheres was %v, expected %v.", result, expected)
+	}
+}
+
+func TestCSGAbsorption(t *testing.T) {
+	m := material.New()
+	m.Ambient = 0
+	m.BSDF = bsdf.NewDielectric(1)
+	m.Absorption, m.Density = colour.New(0.5, 0.5, 0.5), 1
+	left := shape.NewSphere()
+	left.SetMaterial(m)
+	left.SetTransform(matrix.TranslationMatrix(0, 0, -0.5))
+	right := shape.NewSphere()
+	right.SetMaterial(m)
+	right.SetTransform(matrix.TranslationMatrix(0, 0, 0.5))
+	l := light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, 5))
+	w := New()
+	w.Objects = []shape.Shape{shape.NewCSG(shape.CSGUnion, left, right)}
+	w.Lights = []light.Light{l}
+	w.Background = environment.NewSolid(colour.New(1, 1, 1))
+	// The ray goes in through the left sphere and out through the right, and
+	// is absorbed over the three units between.
+	expected := colour.New(0.125, 0.125, 0.125)
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	if result := ColourAt(w, r); result.Equal(expected) != true {
+		t.Errorf("ColourAt through a CSG shape was %v, expected %v.", result, expected)
+	}
+	result := ShadowTransmittance(w, vector.NewPoint(0, 0, -5), l)
+	if result.Equal(expected) != true {
+		t.Errorf("Shadow transmittance through a CSG shape was %v, expected %v.", result, expected)
+	}
+}
+
+func TestCSGFlags(t *testing.T) {
+	l := light.NewPoint(colour.New(1, 1, 1), vector.NewPoint(0, 0, 5))
+	r := ray.New(vector.NewPoint(0, 0, -5), vector.NewVector(0, 0, 1))
+	var tests = []struct {
+		visible, casts bool
+		hit, shadowed  bool
+	}{
+		{visible: true, casts: true, hit: true, shadowed: true},
+		{visible: false, casts: true, hit: false, shadowed: true},
+		{visible: true, casts: false, hit: true, shadowed: false},
+	}
+	for _, test := range tests {
+		hole := shape.NewSphere()
+		hole.SetTransform(matrix.TranslationMatrix(0, 0, 0.5))
+		csg := shape.NewCSG(shape.CSGDifference, shape.NewSphere(), hole)
+		csg.SetVisibleToCamera(test.visible)
+		csg.SetCastsShadows(test.casts)
+		w := New()
+		w.Objects = []shape.Shape{csg}
+		w.Lights = []light.Light{l}
+		if _, err := visibleHit(w, r, true); (err == nil) != test.hit {
+			t.Errorf("Camera hit a CSG shape visible %v: %v, expected %v.", test.visible, err == nil, test.hit)
+		}
+		if result := IsShadowed(w, vector.NewPoint(0, 0, -5), l); result != test.shadowed {
+			t.Errorf(
+				"Point behind a CSG shape casting shadows %v was shadowed %v, expected %v.",
+				test.casts, result, test.shadowed,
+			)
+		}
+	}
+}